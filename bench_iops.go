@@ -48,14 +48,17 @@ func autoIOPSFileSize(disk DiskInfo) int64 {
 		return _256MB
 	case "usb":
 		return _128MB
-	case "nfs":
-		return _256MB
+	case "tmpfs", "zram":
+		return _128MB
 	default:
+		if isNetworkType(disk.DiskType) {
+			return _256MB
+		}
 		return _1GB
 	}
 }
 
-func iopsTest(testDir string, duration int, useSync bool, disk DiskInfo) []IOPSResult {
+func iopsTest(testDir string, duration int, useSync bool, disk DiskInfo, mon benchObserver) []IOPSResult {
 	if duration <= 0 {
 		duration = 10
 	}
@@ -70,45 +73,55 @@ func iopsTest(testDir string, duration int, useSync bool, disk DiskInfo) []IOPSR
 	fileSize := checkAvailableSpace(testDir, autoIOPSFileSize(disk))
 
 	// Create test file
-	fmt.Fprintf(os.Stdout, "  Preparing IOPS test file (%s)...", formatSize(fileSize))
+	fmt.Fprintf(progressOut, "  Preparing IOPS test file (%s)...", formatSize(fileSize))
 	if err := createTestFile(testFile, fileSize); err != nil {
-		fmt.Fprintf(os.Stdout, " error: %v\n", err)
+		fmt.Fprintf(progressOut, " error: %v\n", err)
 		return nil
 	}
-	fmt.Fprintf(os.Stdout, " done.\n")
+	fmt.Fprintf(progressOut, " done.\n")
 	if useSync {
-		fmt.Fprintf(os.Stdout, "  %sNote: --sync enabled, fsync after each write (measures real disk)%s\n", colorYellow, colorReset)
+		fmt.Fprintf(progressOut, "  %sNote: --sync enabled, fsync after each write (measures real disk)%s\n", colorYellow, colorReset)
 	}
 
-	numPositions := int64(fileSize / iopsBlockSize)
+	// 4K, unless direct I/O on this file needs larger transfers
+	bs := benchAlignment(testFile).roundUp(iopsBlockSize)
+	numPositions := fileSize / int64(bs)
 	var results []IOPSResult
+	var mode achievedMode
 
 	// QD1 Write
-	writeIOPS, writeLat := iopsWriteQD1(testFile, numPositions, duration, useSync)
-	fmt.Fprintf(os.Stdout, "  Random Write QD1: %10s IOPS\n", formatNumber(int64(writeIOPS)))
+	mon.setPhase("Random Write QD1")
+	writeIOPS, writeLat := iopsWriteQD1(testFile, numPositions, bs, duration, useSync, &mode, mon)
+	fmt.Fprintf(progressOut, "  Random Write QD1: %10s IOPS\n", formatNumber(int64(writeIOPS)))
 
 	// QD1 Read
-	readIOPS, readLat := iopsReadQD1(testFile, numPositions, duration)
-	fmt.Fprintf(os.Stdout, "  Random Read  QD1: %10s IOPS\n", formatNumber(int64(readIOPS)))
+	mon.setPhase("Random Read QD1")
+	readIOPS, readLat := iopsReadQD1(testFile, numPositions, bs, duration, &mode, mon)
+	fmt.Fprintf(progressOut, "  Random Read  QD1: %10s IOPS\n", formatNumber(int64(readIOPS)))
 
+	ioMode, fallback := mode.get()
 	results = append(results, IOPSResult{
 		Label: "QD1", ReadIOPS: readIOPS, WriteIOPS: writeIOPS,
 		ReadLatencyUS: readLat, WriteLatencyUS: writeLat,
-		QueueDepth: 1, BlockSize: iopsBlockSize, Duration: float64(duration),
+		QueueDepth: 1, BlockSize: bs, Duration: float64(duration), IOMode: ioMode, IOFallback: fallback,
 	})
 
 	// QD4 Write
-	writeIOPS4, writeLat4 := iopsWriteQD(testFile, numPositions, duration, 4, useSync)
-	fmt.Fprintf(os.Stdout, "  Random Write QD4: %10s IOPS\n", formatNumber(int64(writeIOPS4)))
+	mon.setPhase("Random Write QD4")
+	writeIOPS4, writeLat4 := iopsWriteQD(testFile, numPositions, bs, duration, 4, useSync, &mode, mon)
+	fmt.Fprintf(progressOut, "  Random Write QD4: %10s IOPS\n", formatNumber(int64(writeIOPS4)))
 
 	// QD4 Read
-	readIOPS4, readLat4 := iopsReadQD(testFile, numPositions, duration, 4)
-	fmt.Fprintf(os.Stdout, "  Random Read  QD4: %10s IOPS\n", formatNumber(int64(readIOPS4)))
+	mon.setPhase("Random Read QD4")
+	readIOPS4, readLat4 := iopsReadQD(testFile, numPositions, bs, duration, 4, &mode, mon)
+	mon.setPhase("")
+	fmt.Fprintf(progressOut, "  Random Read  QD4: %10s IOPS\n", formatNumber(int64(readIOPS4)))
 
+	ioMode, fallback = mode.get()
 	results = append(results, IOPSResult{
 		Label: "QD4", ReadIOPS: readIOPS4, WriteIOPS: writeIOPS4,
 		ReadLatencyUS: readLat4, WriteLatencyUS: writeLat4,
-		QueueDepth: 4, BlockSize: iopsBlockSize, Duration: float64(duration),
+		QueueDepth: 4, BlockSize: bs, Duration: float64(duration), IOMode: ioMode, IOFallback: fallback,
 	})
 
 	return results
@@ -135,19 +148,19 @@ func createTestFile(path string, size int64) error {
 	return f.Sync()
 }
 
-func randomOffset(numPositions int64) int64 {
+func randomOffset(numPositions int64, bs int) int64 {
 	n, _ := rand.Int(rand.Reader, big.NewInt(numPositions))
-	return n.Int64() * iopsBlockSize
+	return n.Int64() * int64(bs)
 }
 
-func iopsWriteQD1(path string, numPositions int64, duration int, useSync bool) (iops float64, latencyUS float64) {
-	f, err := os.OpenFile(path, os.O_RDWR, 0)
+func iopsWriteQD1(path string, numPositions int64, bs, duration int, useSync bool, mode *achievedMode, mon benchObserver) (iops float64, latencyUS float64) {
+	f, err := openBenchFile(path, os.O_RDWR, mode)
 	if err != nil {
 		return 0, 0
 	}
 	defer f.Close()
 
-	data := make([]byte, iopsBlockSize)
+	data := alignedBuffer(bs, benchAlignment(path).Mem)
 	rand.Read(data)
 
 	ops := int64(0)
@@ -155,7 +168,7 @@ func iopsWriteQD1(path string, numPositions int64, duration int, useSync bool) (
 	deadline := time.Now().Add(time.Duration(duration) * time.Second)
 
 	for time.Now().Before(deadline) {
-		offset := randomOffset(numPositions)
+		offset := randomOffset(numPositions, bs)
 		t0 := time.Now()
 		f.WriteAt(data, offset)
 		if useSync {
@@ -163,6 +176,7 @@ func iopsWriteQD1(path string, numPositions int64, duration int, useSync bool) (
 		}
 		totalLat += time.Since(t0).Seconds()
 		ops++
+		mon.addBytes(bs)
 	}
 
 	elapsed := float64(duration)
@@ -173,28 +187,25 @@ func iopsWriteQD1(path string, numPositions int64, duration int, useSync bool) (
 	return
 }
 
-func iopsReadQD1(path string, numPositions int64, duration int) (iops float64, latencyUS float64) {
-	f, _ := openDirectRead(path)
-	if f == nil {
-		var err error
-		f, err = os.Open(path)
-		if err != nil {
-			return 0, 0
-		}
+func iopsReadQD1(path string, numPositions int64, bs, duration int, mode *achievedMode, mon benchObserver) (iops float64, latencyUS float64) {
+	f, err := openBenchFile(path, os.O_RDONLY, mode)
+	if err != nil {
+		return 0, 0
 	}
 	defer f.Close()
 
-	buf := alignedBuffer(iopsBlockSize)
+	buf := alignedBuffer(bs, benchAlignment(path).Mem)
 	ops := int64(0)
 	totalLat := float64(0)
 	deadline := time.Now().Add(time.Duration(duration) * time.Second)
 
 	for time.Now().Before(deadline) {
-		offset := randomOffset(numPositions)
+		offset := randomOffset(numPositions, bs)
 		t0 := time.Now()
 		f.ReadAt(buf, offset)
 		totalLat += time.Since(t0).Seconds()
 		ops++
+		mon.addBytes(bs)
 	}
 
 	elapsed := float64(duration)
@@ -205,7 +216,7 @@ func iopsReadQD1(path string, numPositions int64, duration int) (iops float64, l
 	return
 }
 
-func iopsWriteQD(path string, numPositions int64, duration, qd int, useSync bool) (iops float64, latencyUS float64) {
+func iopsWriteQD(path string, numPositions int64, bs, duration, qd int, useSync bool, mode *achievedMode, mon benchObserver) (iops float64, latencyUS float64) {
 	var totalOps int64
 	var totalLat int64 // nanoseconds, atomic
 	var wg sync.WaitGroup
@@ -216,20 +227,20 @@ func iopsWriteQD(path string, numPositions int64, duration, qd int, useSync bool
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			f, err := openBenchFile(path, os.O_RDWR, mode)
 			if err != nil {
 				return
 			}
 			defer f.Close()
 
-			data := make([]byte, iopsBlockSize)
+			data := alignedBuffer(bs, benchAlignment(path).Mem)
 			rand.Read(data)
 
 			localOps := int64(0)
 			localLat := int64(0)
 
 			for time.Now().Before(deadline) {
-				offset := randomOffset(numPositions)
+				offset := randomOffset(numPositions, bs)
 				t0 := time.Now()
 				f.WriteAt(data, offset)
 				if useSync {
@@ -237,6 +248,7 @@ func iopsWriteQD(path string, numPositions int64, duration, qd int, useSync bool
 				}
 				localLat += time.Since(t0).Nanoseconds()
 				localOps++
+				mon.addBytes(bs)
 			}
 
 			atomic.AddInt64(&totalOps, localOps)
@@ -253,7 +265,7 @@ func iopsWriteQD(path string, numPositions int64, duration, qd int, useSync bool
 	return
 }
 
-func iopsReadQD(path string, numPositions int64, duration, qd int) (iops float64, latencyUS float64) {
+func iopsReadQD(path string, numPositions int64, bs, duration, qd int, mode *achievedMode, mon benchObserver) (iops float64, latencyUS float64) {
 	var totalOps int64
 	var totalLat int64
 	var wg sync.WaitGroup
@@ -265,26 +277,23 @@ func iopsReadQD(path string, numPositions int64, duration, qd int) (iops float64
 		go func() {
 			defer wg.Done()
 
-			f, _ := openDirectRead(path)
-			if f == nil {
-				var err error
-				f, err = os.Open(path)
-				if err != nil {
-					return
-				}
+			f, err := openBenchFile(path, os.O_RDONLY, mode)
+			if err != nil {
+				return
 			}
 			defer f.Close()
 
-			buf := alignedBuffer(iopsBlockSize)
+			buf := alignedBuffer(bs, benchAlignment(path).Mem)
 			localOps := int64(0)
 			localLat := int64(0)
 
 			for time.Now().Before(deadline) {
-				offset := randomOffset(numPositions)
+				offset := randomOffset(numPositions, bs)
 				t0 := time.Now()
 				f.ReadAt(buf, offset)
 				localLat += time.Since(t0).Nanoseconds()
 				localOps++
+				mon.addBytes(bs)
 			}
 
 			atomic.AddInt64(&totalOps, localOps)
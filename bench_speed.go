@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -43,7 +44,20 @@ func cleanupAll() {
 
 const defaultBlockSize = 1024 * 1024 // 1MB
 
-func speedTest(testDir string, totalSize int64, blockSize int) SpeedResult {
+// progressOut receives the benchmarks' progress lines. --parallel silences
+// it in favour of one combined status line.
+var progressOut io.Writer = os.Stdout
+
+// benchObserver is told which phase a benchmark is in and how many bytes
+// it moved: the thermal monitor and the parallel progress display.
+type benchObserver interface {
+	setPhase(phase string)
+	addBytes(n int)
+}
+
+// speedTest writes and then reads back a totalSize file sequentially. With
+// more than one stream or request in flight it runs speedTestStreams.
+func speedTest(testDir string, totalSize int64, blockSize, streams, qd int, mon benchObserver) SpeedResult {
 	if blockSize <= 0 {
 		blockSize = defaultBlockSize
 	}
@@ -55,33 +69,38 @@ func speedTest(testDir string, totalSize int64, blockSize int) SpeedResult {
 		unregisterCleanup(testFile)
 	}()
 
-	numBlocks := int(totalSize) / blockSize
-	if numBlocks < 1 {
-		numBlocks = 1
+	if streams > 1 || qd > 1 {
+		return speedTestStreams(testFile, totalSize, blockSize, max(streams, 1), max(qd, 1), mon)
 	}
 
-	// Pre-generate random data block
-	dataBlock := make([]byte, blockSize)
-	rand.Read(dataBlock)
-
 	result := SpeedResult{
-		TestSize:  totalSize,
-		BlockSize: blockSize,
+		TestSize:   totalSize,
+		Streams:    1,
+		QueueDepth: 1,
 	}
 
 	// === WRITE TEST ===
-	writeFile, directWrite := openDirectWrite(testFile)
-	if writeFile == nil {
-		// Fallback to normal open
-		var err error
-		writeFile, err = os.OpenFile(testFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Error creating test file: %v\n", err)
-			return result
-		}
-		setNoCache(writeFile)
+	var mode achievedMode
+	writeFile, err := openBenchFile(testFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, &mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Error creating test file: %v\n", err)
+		return result
 	}
 
+	// The file exists now, so its direct I/O alignment can be queried
+	result.Align = benchAlignment(testFile)
+	blockSize = result.Align.roundUp(blockSize)
+	result.BlockSize = blockSize
+	numBlocks := int(totalSize) / blockSize
+	if numBlocks < 1 {
+		numBlocks = 1
+	}
+
+	// Pre-generate random data block
+	dataBlock := alignedBuffer(blockSize, result.Align.Mem)
+	rand.Read(dataBlock)
+
+	mon.setPhase("Sequential Write")
 	start := time.Now()
 	for i := 0; i < numBlocks; i++ {
 		_, err := writeFile.Write(dataBlock)
@@ -89,34 +108,33 @@ func speedTest(testDir string, totalSize int64, blockSize int) SpeedResult {
 			fmt.Fprintf(os.Stderr, "  Write error at block %d: %v\n", i, err)
 			break
 		}
+		mon.addBytes(blockSize)
 		// Progress bar
 		frac := float64(i+1) / float64(numBlocks)
 		speed := float64((i+1)*blockSize) / time.Since(start).Seconds() / (1024 * 1024)
-		fmt.Fprintf(os.Stdout, "\r  Sequential Write:  %s  %s MB/s", progressBar(frac, 24), formatFloat(speed, 1))
+		fmt.Fprintf(progressOut, "\r  Sequential Write:  %s  %s MB/s", progressBar(frac, 24), formatFloat(speed, 1))
 	}
 	writeFile.Sync()
 	writeElapsed := time.Since(start)
 	writeFile.Close()
+	mon.setPhase("")
 
 	result.WriteMBPS = float64(totalSize) / writeElapsed.Seconds() / (1024 * 1024)
-	fmt.Fprintf(os.Stdout, "\r  Sequential Write:  %s  %s MB/s\n", progressBar(1.0, 24), formatFloat(result.WriteMBPS, 1))
+	fmt.Fprintf(progressOut, "\r  Sequential Write:  %s  %s MB/s\n", progressBar(1.0, 24), formatFloat(result.WriteMBPS, 1))
 
 	// === DROP CACHES ===
 	dropCaches()
 
 	// === READ TEST ===
-	readFile, directRead := openDirectRead(testFile)
-	if readFile == nil {
-		var err error
-		readFile, err = os.Open(testFile)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Error opening test file for read: %v\n", err)
-			return result
-		}
+	readFile, err := openBenchFile(testFile, os.O_RDONLY, &mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Error opening test file for read: %v\n", err)
+		return result
 	}
-	result.DirectIO = directWrite || directRead
+	result.IOMode, result.IOFallback = mode.get()
 
-	readBuf := alignedBuffer(blockSize)
+	readBuf := alignedBuffer(blockSize, result.Align.Mem)
+	mon.setPhase("Sequential Read")
 	start = time.Now()
 	totalRead := int64(0)
 	for totalRead < totalSize {
@@ -128,18 +146,182 @@ func speedTest(testDir string, totalSize int64, blockSize int) SpeedResult {
 			break
 		}
 		totalRead += int64(n)
+		mon.addBytes(n)
 
 		frac := float64(totalRead) / float64(totalSize)
 		speed := float64(totalRead) / time.Since(start).Seconds() / (1024 * 1024)
-		fmt.Fprintf(os.Stdout, "\r  Sequential Read:   %s  %s MB/s", progressBar(frac, 24), formatFloat(speed, 1))
+		fmt.Fprintf(progressOut, "\r  Sequential Read:   %s  %s MB/s", progressBar(frac, 24), formatFloat(speed, 1))
 	}
 	readElapsed := time.Since(start)
 	readFile.Close()
+	mon.setPhase("")
 
 	if totalRead > 0 {
 		result.ReadMBPS = float64(totalRead) / readElapsed.Seconds() / (1024 * 1024)
 	}
-	fmt.Fprintf(os.Stdout, "\r  Sequential Read:   %s  %s MB/s\n", progressBar(1.0, 24), formatFloat(result.ReadMBPS, 1))
+	fmt.Fprintf(progressOut, "\r  Sequential Read:   %s  %s MB/s\n", progressBar(1.0, 24), formatFloat(result.ReadMBPS, 1))
 
 	return result
 }
+
+// speedTestStreams splits the test file into one region per stream and
+// moves every region front to back at the same time. Each stream keeps qd
+// requests in flight with one goroutine per slot, taking consecutive blocks
+// from the stream's shared cursor.
+func speedTestStreams(testFile string, totalSize int64, blockSize, streams, qd int, mon benchObserver) SpeedResult {
+	result := SpeedResult{
+		Streams:    streams,
+		QueueDepth: qd,
+	}
+	f, err := os.Create(testFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Error creating test file: %v\n", err)
+		return result
+	}
+	f.Close()
+	result.Align = benchAlignment(testFile)
+	blockSize = result.Align.roundUp(blockSize)
+	region := max(totalSize/int64(streams)/int64(blockSize), 1) * int64(blockSize)
+	result.BlockSize = blockSize
+	result.TestSize = region * int64(streams)
+	if err := os.Truncate(testFile, result.TestSize); err != nil {
+		fmt.Fprintf(os.Stderr, "  Error creating test file: %v\n", err)
+		return result
+	}
+
+	var mode achievedMode
+	result.WriteMBPS, result.WriteStreamMBPS, err = seqStreamPhase(testFile, true, region, blockSize, streams, qd, result.Align.Mem, &mode, mon)
+	result.IOMode, result.IOFallback = mode.get()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Write error: %v\n", err)
+		return result
+	}
+
+	dropCaches()
+
+	result.ReadMBPS, result.ReadStreamMBPS, err = seqStreamPhase(testFile, false, region, blockSize, streams, qd, result.Align.Mem, &mode, mon)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Read error: %v\n", err)
+	}
+	result.IOMode, result.IOFallback = mode.get()
+	return result
+}
+
+// seqStreamPhase runs the write or read half of speedTestStreams and
+// returns the aggregate and per-stream MB/s. Buffers are aligned to
+// memAlign and the mode of every handle is noted in mode.
+func seqStreamPhase(path string, write bool, region int64, blockSize, streams, qd, memAlign int, mode *achievedMode, mon benchObserver) (float64, []float64, error) {
+	label := "Sequential Read"
+	if write {
+		label = "Sequential Write"
+	}
+	total := region * int64(streams)
+	bs := int64(blockSize)
+
+	cursors := make([]atomic.Int64, streams)
+	moved := make([]atomic.Int64, streams)
+	elapsed := make([]time.Duration, streams)
+	var errMu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		errMu.Unlock()
+	}
+
+	mon.setPhase(label)
+	defer mon.setPhase("")
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(done)
+		tick := time.NewTicker(200 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+			}
+			var n int64
+			for i := range moved {
+				n += moved[i].Load()
+			}
+			speed := float64(n) / time.Since(start).Seconds() / (1024 * 1024)
+			fmt.Fprintf(progressOut, "\r  %-19s%s  %s MB/s", label+":", progressBar(float64(n)/float64(total), 24), formatFloat(speed, 1))
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for s := 0; s < streams; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var slots sync.WaitGroup
+			for q := 0; q < qd; q++ {
+				slots.Add(1)
+				go func() {
+					defer slots.Done()
+					flag := os.O_RDONLY
+					if write {
+						flag = os.O_RDWR
+					}
+					f, err := openBenchFile(path, flag, mode)
+					if err != nil {
+						fail(err)
+						return
+					}
+					defer f.Close()
+
+					buf := alignedBuffer(blockSize, memAlign)
+					if write {
+						rand.Read(buf)
+					}
+					for {
+						pos := cursors[s].Add(bs) - bs
+						if pos >= region {
+							break
+						}
+						off := int64(s)*region + pos
+						var err error
+						if write {
+							_, err = f.WriteAt(buf, off)
+						} else {
+							_, err = f.ReadAt(buf, off)
+						}
+						if err != nil {
+							fail(fmt.Errorf("stream %d at offset %d: %w", s+1, off, err))
+							return
+						}
+						moved[s].Add(bs)
+						mon.addBytes(blockSize)
+					}
+					if write {
+						if err := f.Sync(); err != nil {
+							fail(err)
+						}
+					}
+				}()
+			}
+			slots.Wait()
+			elapsed[s] = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	aggElapsed := time.Since(start)
+	close(stop)
+	<-done
+
+	var n int64
+	perStream := make([]float64, streams)
+	for i := range moved {
+		n += moved[i].Load()
+		perStream[i] = float64(moved[i].Load()) / elapsed[i].Seconds() / (1024 * 1024)
+	}
+	mbps := float64(n) / aggElapsed.Seconds() / (1024 * 1024)
+	fmt.Fprintf(progressOut, "\r  %-19s%s  %s MB/s\n", label+":", progressBar(1.0, 24), formatFloat(mbps, 1))
+	return mbps, perStream, firstErr
+}
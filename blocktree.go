@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// blockNode is a disk, partition or dm/md holder in the --list --tree view.
+type blockNode struct {
+	Name      string // sda, sda1, dm-0
+	Kind      string // disk, part, LVM, dm-crypt, md raid1, ...
+	Detail    string // model for disks, mapper name for dm
+	SizeBytes int64
+	FSType    string
+	Label     string
+	UUID      string
+	Mounts    []string
+	Used      int64 // bytes used on the filesystem, 0 if not mounted
+	Free      int64 // bytes available to unprivileged users
+	Children  []blockNode
+}
+
+// treeNode converts the block node into a printable tree.
+func (n blockNode) treeNode() treeNode {
+	parts := []string{n.Name, n.Kind}
+	if n.Detail != "" {
+		parts = append(parts, n.Detail)
+	}
+	parts = append(parts, formatSize(n.SizeBytes))
+	if n.FSType != "" {
+		parts = append(parts, n.FSType)
+	}
+	if n.Label != "" {
+		parts = append(parts, fmt.Sprintf("%q", n.Label))
+	}
+	if n.UUID != "" {
+		parts = append(parts, n.UUID)
+	}
+	if len(n.Mounts) > 0 {
+		parts = append(parts, colorCyan+strings.Join(n.Mounts, ", ")+colorReset)
+		if n.Used > 0 || n.Free > 0 {
+			parts = append(parts, fmt.Sprintf("%sused %s, free %s%s",
+				colorDim, formatSize(n.Used), formatSize(n.Free), colorReset))
+		}
+	}
+	t := treeNode{Label: strings.Join(parts, "  ")}
+	for _, c := range n.Children {
+		t.Children = append(t.Children, c.treeNode())
+	}
+	return t
+}
+
+// printDiskTree prints each disk with its partitions, filesystems and holders.
+func printDiskTree(disks []DiskInfo) {
+	nodes := blockTreePlatform(disks)
+	if nodes == nil {
+		fmt.Printf("  %sTree view is only available on Linux; showing the flat list.%s\n\n", colorDim, colorReset)
+		printDiskList(disks)
+		return
+	}
+	for _, n := range nodes {
+		printTree([]treeNode{n.treeNode()}, "  ")
+	}
+	// Network shares have no block device tree; list them after the disks.
+	for _, d := range disks {
+		if isNetworkType(d.DiskType) || d.DiskType == "9p" || d.DiskType == "virtiofs" {
+			fmt.Printf("  %s  %s  %s  %s%s%s\n", d.Device, d.Interface, d.FSType, colorCyan, d.MountPoint, colorReset)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// blockTreePlatform builds the partition and holder tree for each detected
+// block device from sysfs, mountinfo and the udev database.
+func blockTreePlatform(disks []DiskInfo) []blockNode {
+	mounts := readMountinfo()
+	data, _ := os.ReadFile("/proc/mdstat")
+	mdstat := parseMdstat(string(data))
+
+	var nodes []blockNode
+	for _, d := range disks {
+		if !strings.HasPrefix(d.Device, "/dev/") {
+			continue
+		}
+		name := filepath.Base(d.Device)
+		n := blockTreeNode(name, mounts, mdstat, 0)
+		if d.Name != "Unknown" {
+			n.Detail = d.Name
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+func blockTreeNode(name string, mounts []mountEntry, mdstat map[string]*MDArray, depth int) blockNode {
+	sys := filepath.Join("/sys/class/block", name)
+	n := blockNode{Name: name, Kind: "disk"}
+	if sectors, err := strconv.ParseInt(readSysfsFile(filepath.Join(sys, "size")), 10, 64); err == nil {
+		n.SizeBytes = sectors * 512
+	}
+
+	switch {
+	case fileExists(filepath.Join(sys, "partition")):
+		n.Kind = "part"
+	case strings.HasPrefix(name, "dm-"):
+		n.Kind = dmKind(readSysfsFile(filepath.Join(sys, "dm", "uuid")))
+		n.Detail = readSysfsFile(filepath.Join(sys, "dm", "name"))
+	case strings.HasPrefix(name, "md"):
+		n.Kind = "md"
+		if a := mdstat[name]; a != nil {
+			n.Kind = strings.TrimSpace("md " + a.Level)
+			if s := a.summary(); s != "clean" {
+				n.Detail = s
+			}
+		}
+	}
+
+	dev := readSysfsFile(filepath.Join(sys, "dev"))
+	n.FSType, n.Label, n.UUID = udevFilesystem(dev)
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if dev == "" || dev != strconv.FormatUint(uint64(m.Major), 10)+":"+strconv.FormatUint(uint64(m.Minor), 10) {
+			continue
+		}
+		if n.FSType == "" {
+			n.FSType = m.FSType
+		}
+		if !seen[m.MountPoint] {
+			seen[m.MountPoint] = true
+			n.Mounts = append(n.Mounts, m.MountPoint)
+		}
+	}
+	if len(n.Mounts) > 0 {
+		var st syscall.Statfs_t
+		if syscall.Statfs(n.Mounts[0], &st) == nil {
+			n.Used = int64(st.Blocks-st.Bfree) * int64(st.Bsize)
+			n.Free = int64(st.Bavail) * int64(st.Bsize)
+		}
+	}
+
+	if depth >= 16 {
+		return n // guard against sysfs loops
+	}
+	// Partitions (whole disks only), then anything stacked on this device.
+	if n.Kind == "disk" {
+		parts, _ := filepath.Glob(filepath.Join("/sys/block", name, "*", "partition"))
+		sort.Slice(parts, func(i, j int) bool {
+			a, b := filepath.Base(filepath.Dir(parts[i])), filepath.Base(filepath.Dir(parts[j]))
+			return len(a) < len(b) || len(a) == len(b) && a < b // sda2 before sda10
+		})
+		for _, p := range parts {
+			n.Children = append(n.Children, blockTreeNode(filepath.Base(filepath.Dir(p)), mounts, mdstat, depth+1))
+		}
+	}
+	holders, _ := os.ReadDir(filepath.Join(sys, "holders"))
+	for _, h := range holders {
+		n.Children = append(n.Children, blockTreeNode(h.Name(), mounts, mdstat, depth+1))
+	}
+	return n
+}
+
+// udevFilesystem reads the filesystem type, label and UUID udev probed for
+// a device (major:minor).
+func udevFilesystem(dev string) (fsType, label, uuid string) {
+	if dev == "" {
+		return
+	}
+	data, _ := os.ReadFile(filepath.Join("/run/udev/data", "b"+dev))
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "E:ID_FS_TYPE="); ok {
+			fsType = v
+		} else if v, ok := strings.CutPrefix(line, "E:ID_FS_LABEL="); ok {
+			label = v
+		} else if v, ok := strings.CutPrefix(line, "E:ID_FS_UUID="); ok {
+			uuid = v
+		}
+	}
+	return
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func blockTreePlatform(disks []DiskInfo) []blockNode {
+	return nil // partition and holder tree comes from Linux sysfs
+}
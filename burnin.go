@@ -0,0 +1,587 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// burnInOptions configure a --burn-in soak test.
+type burnInOptions struct {
+	Duration  time.Duration
+	Interval  time.Duration // between health snapshots
+	ReportDir string
+	Size      int64 // verify file size, 0 = automatic
+}
+
+const (
+	burnInRandomPhase   = 2 * time.Minute  // mixed random I/O per cycle
+	burnInRandomQD      = 4                // concurrent random workers
+	burnInStatusEvery   = time.Minute      // status line interval
+	burnInMaxErrors     = 10               // error messages kept per disk
+	burnInPerfDrop      = 0.2              // performance loss reported as degradation
+	burnInSnapshotLimit = 30 * time.Second // shortest snapshot interval
+)
+
+// burnInCycle is the performance of one write/verify/random cycle.
+type burnInCycle struct {
+	Time       time.Time `json:"time"`
+	WriteMBPS  float64   `json:"write_mbps"`
+	ReadMBPS   float64   `json:"read_mbps"`
+	RandomIOPS float64   `json:"random_iops"`
+}
+
+// burnInSnapshot is a health reading taken during the burn-in.
+type burnInSnapshot struct {
+	Time               time.Time `json:"time"`
+	Status             string    `json:"status"`
+	Temperature        int       `json:"temperature"`
+	ReallocatedSectors int       `json:"reallocated_sectors"`
+	PendingSectors     int       `json:"pending_sectors"`
+	MediaErrors        int       `json:"media_errors"`
+	KernelErrors       int       `json:"kernel_errors"`
+}
+
+// burnInReport is the acceptance report for one disk, saved as JSON.
+type burnInReport struct {
+	Device       string           `json:"device"`
+	Model        string           `json:"model"`
+	Serial       string           `json:"serial"`
+	Start        time.Time        `json:"start"`
+	End          time.Time        `json:"end"`
+	Result       string           `json:"result"` // PASS, FAIL, RUNNING
+	IOMode       string           `json:"io_mode,omitempty"`
+	IOFallback   string           `json:"io_fallback,omitempty"` // why direct I/O was not used
+	Failures     []string         `json:"failures,omitempty"`
+	Warnings     []string         `json:"warnings,omitempty"`
+	BytesWritten int64            `json:"bytes_written"`
+	BytesRead    int64            `json:"bytes_read"`
+	IOErrors     int              `json:"io_errors"`
+	Mismatches   int              `json:"mismatches"`
+	Errors       []string         `json:"errors,omitempty"`
+	Cycles       []burnInCycle    `json:"cycles"`
+	Snapshots    []burnInSnapshot `json:"snapshots"`
+}
+
+// burnInWorker runs the workload on one disk.
+type burnInWorker struct {
+	disk DiskInfo
+	dir  string
+	file string
+	size int64
+	act  *diskActivity
+
+	written atomic.Int64
+	read    atomic.Int64
+	mode    achievedMode
+
+	mu     sync.Mutex
+	report burnInReport
+}
+
+// mix64 is the splitmix64 finaliser.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// burnInPattern fills buf with the data expected at file offset off. Every
+// 8-byte word is derived from the cycle seed and its own offset, so blocks
+// written to the wrong place or left stale fail verification as well as
+// flipped bits.
+func burnInPattern(buf []byte, seed uint64, off int64) {
+	for i := 0; i+8 <= len(buf); i += 8 {
+		binary.LittleEndian.PutUint64(buf[i:], mix64(seed^uint64(off+int64(i))))
+	}
+}
+
+// countMismatches compares data read at off with the pattern and returns
+// the number of 4K blocks that differ.
+func countMismatches(got, want []byte, seed uint64, off int64) int {
+	burnInPattern(want, seed, off)
+	bad := 0
+	for i := 0; i < len(got); i += iopsBlockSize {
+		end := min(i+iopsBlockSize, len(got))
+		if !bytes.Equal(got[i:end], want[i:end]) {
+			bad++
+		}
+	}
+	return bad
+}
+
+func (w *burnInWorker) ioError(op string, off int64, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.report.IOErrors++
+	if len(w.report.Errors) < burnInMaxErrors {
+		w.report.Errors = append(w.report.Errors,
+			fmt.Sprintf("%s %s at offset %d: %v", time.Now().Format(time.TimeOnly), op, off, err))
+	}
+}
+
+func (w *burnInWorker) mismatch(n int, off int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.report.Mismatches += n
+	if len(w.report.Errors) < burnInMaxErrors {
+		w.report.Errors = append(w.report.Errors,
+			fmt.Sprintf("%s data mismatch: %d block(s) near offset %d", time.Now().Format(time.TimeOnly), n, off))
+	}
+}
+
+// writeSequential writes the whole verify file with the cycle's pattern.
+func (w *burnInWorker) writeSequential(size int64, seed uint64) float64 {
+	f, err := openBenchFile(w.file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, &w.mode)
+	if err != nil {
+		w.ioError("create", 0, err)
+		return 0
+	}
+	defer f.Close()
+
+	buf := alignedBuffer(defaultBlockSize, benchAlignment(w.file).Mem)
+	w.act.setPhase("Sequential Write")
+	defer w.act.setPhase("")
+	start := time.Now()
+	var off int64
+	for off < size {
+		burnInPattern(buf, seed, off)
+		if _, err := f.Write(buf); err != nil {
+			w.ioError("write", off, err)
+			return 0
+		}
+		off += int64(len(buf))
+		w.written.Add(int64(len(buf)))
+		w.act.addBytes(len(buf))
+	}
+	if err := f.Sync(); err != nil {
+		w.ioError("fsync", 0, err)
+	}
+	return float64(off) / time.Since(start).Seconds() / (1024 * 1024)
+}
+
+// verifySequential reads the verify file back and checks the pattern.
+func (w *burnInWorker) verifySequential(size int64, seed uint64) float64 {
+	f, err := openBenchFile(w.file, os.O_RDONLY, &w.mode)
+	if err != nil {
+		w.ioError("open", 0, err)
+		return 0
+	}
+	defer f.Close()
+
+	buf := alignedBuffer(defaultBlockSize, benchAlignment(w.file).Mem)
+	want := make([]byte, defaultBlockSize)
+	w.act.setPhase("Sequential Read")
+	defer w.act.setPhase("")
+	start := time.Now()
+	var off int64
+	for off < size {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			w.ioError("read", off, err)
+			return 0
+		}
+		if n := countMismatches(buf, want, seed, off); n > 0 {
+			w.mismatch(n, off)
+		}
+		off += int64(len(buf))
+		w.read.Add(int64(len(buf)))
+		w.act.addBytes(len(buf))
+	}
+	return float64(off) / time.Since(start).Seconds() / (1024 * 1024)
+}
+
+// randomMixed runs 4K random reads (verified) and writes (of the same
+// pattern, so the file stays verifiable) for d, and returns the IOPS.
+// Blocks grow beyond 4K if direct I/O on the file needs larger transfers.
+func (w *burnInWorker) randomMixed(size int64, seed uint64, d time.Duration) float64 {
+	align := benchAlignment(w.file)
+	bs := int64(align.roundUp(iopsBlockSize))
+	positions := size / bs
+	deadline := time.Now().Add(d)
+	var ops atomic.Int64
+	var wg sync.WaitGroup
+
+	w.act.setPhase("Random Mixed")
+	defer w.act.setPhase("")
+	start := time.Now()
+	for q := 0; q < burnInRandomQD; q++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wf, err := openBenchFile(w.file, os.O_RDWR, &w.mode)
+			if err != nil {
+				w.ioError("open", 0, err)
+				return
+			}
+			defer wf.Close()
+			rf, err := openBenchFile(w.file, os.O_RDONLY, &w.mode)
+			if err != nil {
+				w.ioError("open", 0, err)
+				return
+			}
+			defer rf.Close()
+
+			buf := alignedBuffer(int(bs), align.Mem)
+			want := alignedBuffer(int(bs), align.Mem)
+			rng := rand.New(rand.NewPCG(seed, uint64(q)))
+			for time.Now().Before(deadline) {
+				off := rng.Int64N(positions) * bs
+				if rng.IntN(10) < 3 {
+					burnInPattern(want, seed, off)
+					if _, err := wf.WriteAt(want, off); err != nil {
+						w.ioError("random write", off, err)
+						return
+					}
+					w.written.Add(bs)
+				} else {
+					if _, err := rf.ReadAt(buf, off); err != nil {
+						w.ioError("random read", off, err)
+						return
+					}
+					if n := countMismatches(buf, want, seed, off); n > 0 {
+						w.mismatch(n, off)
+					}
+					w.read.Add(bs)
+				}
+				ops.Add(1)
+				w.act.addBytes(int(bs))
+			}
+			if err := wf.Sync(); err != nil {
+				w.ioError("fsync", 0, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return float64(ops.Load()) / time.Since(start).Seconds()
+}
+
+// run repeats write, verify and random cycles until the deadline. A cycle
+// that has started always finishes its verify pass.
+func (w *burnInWorker) run(deadline time.Time) {
+	registerCleanup(w.file)
+	defer func() {
+		os.Remove(w.file)
+		unregisterCleanup(w.file)
+	}()
+
+	for time.Now().Before(deadline) {
+		seed := rand.Uint64()
+		c := burnInCycle{}
+		c.WriteMBPS = w.writeSequential(w.size, seed)
+		dropCaches()
+		c.ReadMBPS = w.verifySequential(w.size, seed)
+		if d := min(burnInRandomPhase, time.Until(deadline)); d > time.Second {
+			c.RandomIOPS = w.randomMixed(w.size, seed, d)
+		}
+		c.Time = time.Now().UTC()
+
+		w.mu.Lock()
+		w.report.Cycles = append(w.report.Cycles, c)
+		failed := w.report.IOErrors > 0
+		w.mu.Unlock()
+		if failed && c.WriteMBPS == 0 && c.ReadMBPS == 0 {
+			return // the disk stopped accepting I/O: nothing left to learn
+		}
+	}
+}
+
+// snapshot records the disk's current health.
+func (w *burnInWorker) snapshot() {
+	h := checkHealth(w.disk)
+	s := burnInSnapshot{
+		Time: time.Now().UTC(), Status: h.Status, Temperature: h.Temperature,
+		ReallocatedSectors: h.ReallocatedSectors, PendingSectors: h.PendingSectors,
+		MediaErrors: h.MediaErrors,
+	}
+	if h.KernelErrors != nil {
+		s.KernelErrors = h.KernelErrors.Total
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.report.Snapshots) == 0 {
+		w.report.Model = h.Model
+		if w.report.Model == "" {
+			w.report.Model = w.disk.Name
+		}
+		w.report.Serial = healthSerial(w.disk, h)
+	}
+	w.report.Snapshots = append(w.report.Snapshots, s)
+}
+
+// save writes the report so far; a burn-in cut short still leaves one.
+func (w *burnInWorker) save(dir string, final bool) (string, error) {
+	w.mu.Lock()
+	r := w.report
+	r.Cycles = append([]burnInCycle(nil), w.report.Cycles...)
+	r.Snapshots = append([]burnInSnapshot(nil), w.report.Snapshots...)
+	w.mu.Unlock()
+
+	r.End = time.Now().UTC()
+	r.BytesWritten = w.written.Load()
+	r.BytesRead = w.read.Load()
+	r.IOMode, r.IOFallback = w.mode.get()
+	if final {
+		evaluateBurnIn(&r)
+		w.mu.Lock()
+		w.report = r
+		w.mu.Unlock()
+	} else {
+		r.Result = "RUNNING"
+	}
+
+	name := r.Serial
+	if name == "" {
+		name = filepath.Base(r.Device)
+	}
+	file := filepath.Join(dir, fmt.Sprintf("burnin-%s-%s.json", safeFileName(name), r.Start.Local().Format("20060102-150405")))
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return file, err
+	}
+	return file, os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// evaluateBurnIn decides pass or fail. I/O errors, data mismatches, a
+// CRITICAL health reading, growing SMART error counters and new kernel
+// errors fail the disk; health warnings and slowing down are reported.
+func evaluateBurnIn(r *burnInReport) {
+	r.Failures, r.Warnings = nil, nil
+	if r.IOErrors > 0 {
+		r.Failures = append(r.Failures, fmt.Sprintf("%d I/O error(s)", r.IOErrors))
+	}
+	if r.Mismatches > 0 {
+		r.Failures = append(r.Failures, fmt.Sprintf("%d data mismatch(es): data read back differed from data written", r.Mismatches))
+	}
+	if r.IOFallback != "" {
+		r.Warnings = append(r.Warnings, "direct I/O not available ("+r.IOFallback+"): data may have been verified from the page cache")
+	}
+
+	if len(r.Snapshots) > 0 {
+		first, last := r.Snapshots[0], r.Snapshots[len(r.Snapshots)-1]
+		worst := first.Status
+		for _, s := range r.Snapshots {
+			worst = worseStatus(worst, s.Status)
+		}
+		switch {
+		case worst == "CRITICAL":
+			r.Failures = append(r.Failures, "health reached CRITICAL")
+		case worst == "WARNING" && first.Status != "WARNING":
+			r.Warnings = append(r.Warnings, "health reached WARNING")
+		case worst == "UNKNOWN":
+			r.Warnings = append(r.Warnings, "no SMART data: drive health was not monitored")
+		}
+		counters := []struct {
+			name     string
+			from, to int
+		}{
+			{"reallocated sectors", first.ReallocatedSectors, last.ReallocatedSectors},
+			{"pending sectors", first.PendingSectors, last.PendingSectors},
+			{"media errors", first.MediaErrors, last.MediaErrors},
+		}
+		for _, c := range counters {
+			if c.to > c.from {
+				r.Failures = append(r.Failures, fmt.Sprintf("%s grew from %d to %d", c.name, c.from, c.to))
+			}
+		}
+		if n := last.KernelErrors - first.KernelErrors; n > 0 {
+			r.Failures = append(r.Failures, fmt.Sprintf("%d new kernel error message(s)", n))
+		}
+	}
+
+	// Degradation: the last third of the cycles against the first cycle.
+	if n := len(r.Cycles); n >= 3 {
+		tail := r.Cycles[n-max(n/3, 1):]
+		metrics := []struct {
+			name string
+			get  func(burnInCycle) float64
+			unit string
+		}{
+			{"sequential write", func(c burnInCycle) float64 { return c.WriteMBPS }, "MB/s"},
+			{"sequential read", func(c burnInCycle) float64 { return c.ReadMBPS }, "MB/s"},
+			{"random mixed", func(c burnInCycle) float64 { return c.RandomIOPS }, "IOPS"},
+		}
+		for _, m := range metrics {
+			base := m.get(r.Cycles[0])
+			sum := 0.0
+			for _, c := range tail {
+				sum += m.get(c)
+			}
+			end := sum / float64(len(tail))
+			if base > 0 && end < base*(1-burnInPerfDrop) {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s fell %.0f%% (%s -> %s %s)",
+					m.name, (1-end/base)*100, formatFloat(base, 0), formatFloat(end, 0), m.unit))
+			}
+		}
+	}
+
+	r.Result = "PASS"
+	if len(r.Failures) > 0 {
+		r.Result = "FAIL"
+	}
+}
+
+// runBurnIn runs the burn-in on every disk at once and reports pass or
+// fail per disk. It returns false if any disk failed.
+func runBurnIn(disks []DiskInfo, opts burnInOptions) bool {
+	var workers []*burnInWorker
+	seen := make(map[string]bool)
+	for _, d := range disks {
+		dir := benchDir(d, true)
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		workers = append(workers, &burnInWorker{
+			disk: d, dir: dir, file: filepath.Join(dir, ".diskbench_burnin"),
+			act:    &diskActivity{label: filepath.Base(d.Device)},
+			report: burnInReport{Device: d.Device},
+		})
+	}
+	if len(workers) == 0 {
+		fmt.Println("  No writable disks to burn in.")
+		return false
+	}
+	if err := os.MkdirAll(opts.ReportDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: burn-in reports: %v\n", err)
+		return false
+	}
+	interval := max(opts.Interval, burnInSnapshotLimit)
+
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	sep := "━━"
+	if !useUnicode {
+		sep = "=="
+	}
+	fmt.Printf("  %s%s Burn-in: %d disk(s) for %s, until %s %s%s\n", colorBold, sep,
+		len(workers), opts.Duration, deadline.Format("2006-01-02 15:04"), sep, colorReset)
+	fmt.Printf("  %sHealth snapshots every %s, reports in %s%s\n", colorDim, interval, opts.ReportDir, colorReset)
+	fmt.Println()
+
+	acts := make([]*diskActivity, len(workers))
+	for i, w := range workers {
+		w.report.Start = start.UTC()
+		w.snapshot()
+		acts[i] = w.act
+		// Whole 1MB blocks, so direct I/O stays aligned.
+		w.size = max(speedTestSize(w.disk, w.dir, opts.Size)/defaultBlockSize*defaultBlockSize, defaultBlockSize)
+		w.report.Cycles = []burnInCycle{}
+		fmt.Printf("  %s: health %s, verify file %s in %s\n", w.disk.Device, w.report.Snapshots[0].Status,
+			formatSize(w.size), w.dir)
+	}
+	fmt.Println()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go watchProgress(acts, burnInStatusEvery, stop, done)
+
+	finished := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(deadline)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-finished:
+			running = false
+		case <-ticker.C:
+			for _, w := range workers {
+				w.snapshot()
+				if _, err := w.save(opts.ReportDir, false); err != nil {
+					fmt.Fprintf(os.Stderr, "  Warning: burn-in report: %v\n", err)
+				}
+			}
+		}
+	}
+	close(stop)
+	<-done
+
+	passed := true
+	var files []string
+	for _, w := range workers {
+		w.snapshot()
+		file, err := w.save(opts.ReportDir, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: burn-in report: %v\n", err)
+		} else {
+			files = append(files, file)
+		}
+		if w.report.Result != "PASS" {
+			passed = false
+		}
+	}
+	printBurnInReport(workers, files)
+	return passed
+}
+
+// printBurnInReport prints the acceptance summary for every disk.
+func printBurnInReport(workers []*burnInWorker, files []string) {
+	fmt.Println()
+	fmt.Printf("  %sBurn-in results%s\n", colorBold, colorReset)
+	headers := []string{"Device", "Model", "Serial", "Result", "Cycles", "Written", "Read", "I/O Errors", "Mismatches", "Peak Temp"}
+	aligns := []byte{'l', 'l', 'l', 'c', 'r', 'r', 'r', 'r', 'r', 'r'}
+	var rows [][]string
+	for _, w := range workers {
+		r := w.report
+		color := colorGreen
+		if r.Result != "PASS" {
+			color = colorRed
+		}
+		peak := 0
+		for _, s := range r.Snapshots {
+			peak = max(peak, s.Temperature)
+		}
+		temp := "-"
+		if peak > 0 {
+			temp = fmt.Sprintf("%dC", peak)
+		}
+		rows = append(rows, []string{r.Device, r.Model, r.Serial, color + r.Result + colorReset,
+			formatNumber(int64(len(r.Cycles))), formatSize(r.BytesWritten), formatSize(r.BytesRead),
+			formatNumber(int64(r.IOErrors)), formatNumber(int64(r.Mismatches)), temp})
+	}
+	printTable(headers, rows, aligns)
+	fmt.Println()
+
+	for _, w := range workers {
+		r := w.report
+		if len(r.Failures)+len(r.Warnings)+len(r.Errors) == 0 {
+			continue
+		}
+		fmt.Printf("  %s%s%s\n", colorBold, r.Device, colorReset)
+		for _, f := range r.Failures {
+			fmt.Printf("    %sFAIL%s %s\n", colorRed, colorReset, f)
+		}
+		for _, f := range r.Warnings {
+			fmt.Printf("    %sWARN%s %s\n", colorYellow, colorReset, f)
+		}
+		for _, e := range r.Errors {
+			fmt.Printf("    %s%s%s\n", colorDim, e, colorReset)
+		}
+		fmt.Println()
+	}
+	if len(files) > 0 {
+		fmt.Printf("  %sReports: %s%s\n", colorDim, strings.Join(files, ", "), colorReset)
+	}
+}
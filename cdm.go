@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// CrystalDiskMark's default profile: 1 GiB test file, best of 5 runs of
+// 5 seconds with 5 seconds between runs.
+const (
+	cdmTestSize = 1024 * 1024 * 1024
+	cdmRuns     = 5
+	cdmMeasure  = 5 // seconds
+	cdmInterval = 5 * time.Second
+)
+
+// cdmTest is one row of the CrystalDiskMark results.
+type cdmTest struct {
+	Kind    string // SEQ or RND
+	Block   string // 1MiB, 4KiB
+	Queue   int
+	Threads int
+}
+
+// cdmTests are the rows of CrystalDiskMark's default profile, in its order.
+var cdmTests = []cdmTest{
+	{"SEQ", "1MiB", 8, 1},
+	{"SEQ", "1MiB", 1, 1},
+	{"RND", "4KiB", 32, 16},
+	{"RND", "4KiB", 1, 1},
+}
+
+// cdmResult is the best run of one test in one direction.
+type cdmResult struct {
+	MBPS  float64 // decimal: 1 MB/s = 1,000,000 bytes/s, as CrystalDiskMark
+	IOPS  float64
+	LatUS float64
+}
+
+// label formats the test the way CrystalDiskMark's text export does.
+func (t cdmTest) label() string {
+	return fmt.Sprintf("%s %7s (Q=%3d, T=%2d)", t.Kind, t.Block, t.Queue, t.Threads)
+}
+
+// short is the name on CrystalDiskMark's buttons, e.g. SEQ1M Q8T1.
+func (t cdmTest) short() string {
+	return fmt.Sprintf("%s%s Q%dT%d", t.Kind, strings.TrimSuffix(t.Block, "iB"), t.Queue, t.Threads)
+}
+
+func (t cdmTest) workload(read bool) workload {
+	w := workload{
+		Name: t.short(), Pattern: "random", BlockSizes: []string{strings.TrimSuffix(t.Block, "iB")},
+		QueueDepth: t.Queue, Threads: t.Threads, Duration: cdmMeasure,
+	}
+	if t.Kind == "SEQ" {
+		w.Pattern = "sequential"
+	}
+	if read {
+		w.ReadPct = 100
+	}
+	return w
+}
+
+// runCDM runs the CrystalDiskMark default tests on one disk and prints the
+// results in its text export layout.
+func runCDM(disk DiskInfo, testDir string, size int64, mon benchObserver) {
+	testFile := filepath.Join(testDir, ".diskbench_cdm")
+	registerCleanup(testFile)
+	defer func() {
+		os.Remove(testFile)
+		unregisterCleanup(testFile)
+	}()
+
+	if size <= 0 {
+		size = cdmTestSize
+	}
+	size = checkAvailableSpace(testDir, size)
+	fmt.Fprintf(progressOut, "  Preparing CrystalDiskMark test file (%s)...", formatSize(size))
+	if err := createTestFile(testFile, size); err != nil {
+		fmt.Fprintf(progressOut, " error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(progressOut, " done.\n")
+
+	mode := "unknown"
+	var probe achievedMode
+	if f, err := openBenchFile(testFile, os.O_RDWR, &probe); err == nil {
+		f.Close()
+		mode = strings.TrimPrefix(ioModeNote(probe.get()), "using ")
+	}
+
+	reads := make([]cdmResult, len(cdmTests))
+	writes := make([]cdmResult, len(cdmTests))
+	for i, t := range cdmTests {
+		reads[i] = cdmBest(t, true, testFile, size, mon)
+		writes[i] = cdmBest(t, false, testFile, size, mon)
+	}
+	fmt.Println()
+	printCDMReport(disk, testDir, size, mode, reads, writes)
+}
+
+// cdmBest runs a test cdmRuns times and keeps the fastest run, as
+// CrystalDiskMark does.
+func cdmBest(t cdmTest, read bool, path string, size int64, mon benchObserver) cdmResult {
+	dir := "Write"
+	if read {
+		dir = "Read"
+	}
+	var best cdmResult
+	for run := 1; run <= cdmRuns; run++ {
+		r := runWorkload(t.workload(read), path, size, cdmMeasure, mon)
+		mbps := float64(r.Bytes) / r.Seconds / 1e6
+		if mbps > best.MBPS {
+			best = cdmResult{MBPS: mbps, IOPS: r.IOPS, LatUS: r.AvgLatUS}
+		}
+		fmt.Fprintf(progressOut, "\r  %-13s %-5s [%d/%d] %10s MB/s", t.short(), dir, run, cdmRuns, formatFloat(best.MBPS, 2))
+		if run < cdmRuns {
+			time.Sleep(cdmInterval)
+		}
+	}
+	fmt.Fprintf(progressOut, "\n")
+	return best
+}
+
+// printCDMReport prints the results like CrystalDiskMark's text export, so
+// they can be set side by side with its screenshots and saved results.
+func printCDMReport(disk DiskInfo, testDir string, size int64, mode string, reads, writes []cdmResult) {
+	line := strings.Repeat("-", 78)
+	fmt.Println(line)
+	fmt.Printf("diskbench v%s - CrystalDiskMark default profile\n", version)
+	fmt.Println(line)
+	fmt.Println("* MB/s = 1,000,000 bytes/s [SATA/600 = 600,000,000 bytes/s]")
+	fmt.Println("* KB = 1000 bytes, KiB = 1024 bytes")
+	fmt.Println()
+	for _, section := range []struct {
+		name    string
+		results []cdmResult
+	}{{"Read", reads}, {"Write", writes}} {
+		fmt.Printf("[%s]\n", section.name)
+		for i, t := range cdmTests {
+			r := section.results[i]
+			fmt.Printf("  %s: %s%9.3f MB/s%s [%9.1f IOPS] <%9.2f us>\n",
+				t.label(), colorBold, r.MBPS, colorReset, r.IOPS, r.LatUS)
+		}
+		fmt.Println()
+	}
+
+	target := testDir
+	if total := getPartitionSize(testDir); total > 0 {
+		target = fmt.Sprintf("%s: %s", testDir, formatSize(total))
+	}
+	fmt.Println("Profile: Default")
+	fmt.Printf("   Test: %s (x%d) [%s]\n", formatSize(size), cdmRuns, target)
+	fmt.Printf("   Mode: %s\n", mode)
+	fmt.Printf("   Time: Measure %d sec / Interval %d sec\n", cdmMeasure, int(cdmInterval.Seconds()))
+	fmt.Printf("   Date: %s\n", time.Now().Format("2006/01/02 15:04:05"))
+	fmt.Printf("     OS: %s %s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("   Disk: %s (%s)\n", disk.Name, disk.Device)
+	fmt.Println()
+}
@@ -9,13 +9,34 @@ import (
 
 // detectDisks returns all detected disks on the current platform.
 func detectDisks() []DiskInfo {
-	return detectDisksPlatform() // Implemented per-platform
+	disks := detectDisksPlatform() // Implemented per-platform
+	for i := range disks {
+		addDeviceDetails(&disks[i])
+	}
+	return disks
+}
+
+// addDeviceDetails fills in the platform's device properties, link speed
+// and persistent identifiers.
+func addDeviceDetails(d *DiskInfo) {
+	if d.IDs == nil {
+		d.IDs = diskIDsPlatform(d.Device)
+	}
+	if d.Slot == "" {
+		d.Slot = diskSlotPlatform(d.Device)
+	}
+	if d.Props == nil {
+		d.Props = diskPropsPlatform(d.Device)
+	}
+	if d.Link == nil {
+		d.Link = linkInfoPlatform(d.Device)
+	}
 }
 
 // resolveTarget resolves a user-specified path/device to DiskInfo objects.
 func resolveTarget(target string) []DiskInfo {
-	// 1. If directory: findDeviceForPath -> matchToPhysical -> return
-	// 2. If block device (/dev/*, \\.\*): lookup in detectDisks
+	// 1. If directory: findDeviceForPath -> resolveStacked / matchToPhysical -> return
+	// 2. If block device (/dev/*, \\.\*): lookup in detectDisks, then resolveStacked
 	// 3. If file exists: use parent directory
 	// 4. Else: error
 
@@ -26,23 +47,18 @@ func resolveTarget(target string) []DiskInfo {
 	}
 
 	if info.IsDir() {
-		device := findDeviceForPath(target)
-		matched := matchDeviceToPhysical(device)
-		if matched != nil {
-			matched.MountPoint = target
-			return []DiskInfo{*matched}
-		}
-		// Create minimal DiskInfo
-		dtype := guessDiskType(device)
-		size := getPartitionSize(target)
-		name := target
-		if b := filepath.Base(target); b != "." && b != "/" {
-			name = b
+		device, mount := findDeviceForPath(target)
+		disk := resolveMountedDevice(device, target)
+		if mount != nil {
+			if k, ok := lookupFSKind(mount.FSType); ok {
+				disk.DiskType, disk.Interface = k.DiskType, k.Interface
+			}
+			disk.FSType = mount.FSType
+			disk.MountOptions = benchmarkMountOptions(mount.Options)
 		}
-		return []DiskInfo{{
-			Device: device, Name: name, DiskType: dtype,
-			Interface: "Unknown", SizeBytes: size, MountPoint: target,
-		}}
+		addDeviceDetails(&disk)
+		settleDiskType(&disk)
+		return []DiskInfo{disk}
 	}
 
 	// Block device
@@ -53,28 +69,63 @@ func resolveTarget(target string) []DiskInfo {
 				return []DiskInfo{d}
 			}
 		}
-		return []DiskInfo{{Device: target, Name: target, DiskType: "unknown",
-			Interface: "Unknown", MountPoint: ""}}
+		if stacked := resolveStackedDevice(target, ""); stacked != nil {
+			addDeviceDetails(stacked)
+			return []DiskInfo{*stacked}
+		}
+		disk := DiskInfo{Device: target, Name: target, DiskType: "unknown",
+			Interface: "Unknown", MountPoint: ""}
+		addDeviceDetails(&disk)
+		settleDiskType(&disk)
+		return []DiskInfo{disk}
 	}
 
 	// File: use parent directory
 	return resolveTarget(filepath.Dir(target))
 }
 
-// findDeviceForPath uses 'df' to find the block device for a path.
-func findDeviceForPath(path string) string {
+// resolveMountedDevice maps the device a directory lives on to a DiskInfo.
+func resolveMountedDevice(device, target string) DiskInfo {
+	if stacked := resolveStackedDevice(device, target); stacked != nil {
+		return *stacked
+	}
+	matched := matchDeviceToPhysical(device)
+	if matched != nil {
+		matched.MountPoint = target
+		return *matched
+	}
+	// Create minimal DiskInfo
+	dtype := guessDiskType(device)
+	size := getPartitionSize(target)
+	name := target
+	if b := filepath.Base(target); b != "." && b != "/" {
+		name = b
+	}
+	return DiskInfo{
+		Device: device, Name: name, DiskType: dtype,
+		Interface: "Unknown", SizeBytes: size, MountPoint: target,
+	}
+}
+
+// findDeviceForPath finds the block device for a path from the mount table
+// where the platform has one, and otherwise from 'df'. The mount entry is nil
+// when 'df' was used.
+func findDeviceForPath(path string) (string, *mountEntry) {
+	if device, mount := resolveMountPlatform(path); mount != nil {
+		return device, mount
+	}
 	out, err := runCmd("df", path)
 	if err != nil {
-		return path
+		return path, nil
 	}
 	lines := strings.Split(strings.TrimSpace(out), "\n")
 	if len(lines) >= 2 {
 		fields := strings.Fields(lines[1])
 		if len(fields) > 0 {
-			return fields[0]
+			return fields[0], nil
 		}
 	}
-	return path
+	return path, nil
 }
 
 // matchDeviceToPhysical tries to match a partition/volume device to its physical disk.
@@ -104,19 +155,41 @@ func matchDeviceToPhysical(device string) *DiskInfo {
 	return nil
 }
 
-// guessDiskType makes a best guess from a device path string.
+// guessDiskType makes a best guess from a device path string, "unknown"
+// when the name gives nothing away.
 func guessDiskType(device string) string {
 	dev := strings.ToLower(device)
-	if strings.Contains(dev, "nfs") || strings.Contains(dev, ":") {
+	if k, ok := lookupFSKind(dev); ok {
+		return k.DiskType // virtual filesystems name themselves as the source
+	}
+	if strings.HasPrefix(dev, "//") || strings.HasPrefix(dev, `\\`) {
+		return "smb"
+	}
+	if strings.Contains(dev, "nfs") {
 		return "nfs"
 	}
+	if strings.Contains(dev, "zram") {
+		return "zram"
+	}
 	if strings.Contains(dev, "nvme") {
 		return "nvme"
 	}
 	if strings.Contains(dev, "usb") {
 		return "usb"
 	}
-	return "ssd"
+	return "unknown"
+}
+
+// settleDiskType replaces an unknown disk type with what the device's queue
+// properties say: rotational or not.
+func settleDiskType(d *DiskInfo) {
+	if d.DiskType != "unknown" || d.Props == nil {
+		return
+	}
+	d.DiskType = "ssd"
+	if d.Props.Rotational {
+		d.DiskType = "hdd"
+	}
 }
 
 // getPartitionSize returns the total size of the filesystem at path.
@@ -127,9 +200,12 @@ func getPartitionSize(path string) int64 {
 
 // autoTestSize determines the test file size based on disk capacity.
 func autoTestSize(disk DiskInfo) int64 {
-	if disk.DiskType == "nfs" {
+	if isNetworkType(disk.DiskType) {
 		return 512 * 1024 * 1024 // 512MB
 	}
+	if isMemoryType(disk.DiskType) {
+		return 256 * 1024 * 1024 // 256MB, it comes out of RAM
+	}
 	size := disk.SizeBytes
 	switch {
 	case size > 0 && size < 32*1024*1024*1024:
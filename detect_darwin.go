@@ -124,22 +124,27 @@ func detectDisksPlatform() []DiskInfo {
 		})
 	}
 
-	// NFS mounts
+	// Network mounts: "remote on /mnt/point (nfs, nodev, ...)"
 	out, _ := runCmd("mount")
 	for _, line := range strings.Split(out, "\n") {
-		if strings.Contains(strings.ToLower(line), "(nfs") {
-			parts := strings.SplitN(line, " on ", 2)
-			if len(parts) < 2 {
-				continue
-			}
-			remote := strings.TrimSpace(parts[0])
-			rest := strings.SplitN(parts[1], " (", 2)
-			mpoint := strings.TrimSpace(rest[0])
-			disks = append(disks, DiskInfo{
-				Device: remote, Name: remote, DiskType: "nfs",
-				Interface: "Network", MountPoint: mpoint,
-			})
+		parts := strings.SplitN(line, " on ", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		rest := strings.SplitN(parts[1], " (", 2)
+		if len(rest) < 2 {
+			continue
+		}
+		fsType, _, _ := strings.Cut(strings.TrimSuffix(rest[1], ")"), ",")
+		k, ok := lookupFSKind(strings.ToLower(fsType))
+		if !ok || !k.Shared {
+			continue
 		}
+		remote := strings.TrimSpace(parts[0])
+		disks = append(disks, DiskInfo{
+			Device: remote, Name: remote, DiskType: k.DiskType,
+			Interface: k.Interface, MountPoint: strings.TrimSpace(rest[0]),
+		})
 	}
 
 	return disks
@@ -291,6 +296,10 @@ func extractBaseDisk(s string) string {
 	return s
 }
 
+func scsiHostPlatform(device string) int {
+	return -1 // controller topology is only resolved on Linux
+}
+
 func getPartitionSizePlatform(path string) int64 {
 	var stat syscall.Statfs_t
 	if syscall.Statfs(path, &stat) != nil {
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 )
@@ -32,115 +33,121 @@ type lsblkDevice struct {
 func detectDisksPlatform() []DiskInfo {
 	var disks []DiskInfo
 
-	// Block devices via lsblk
+	// Block devices via lsblk, or straight from sysfs when lsblk is missing
+	// (minimal containers, initramfs).
+	var lsblk lsblkOutput
 	out, err := runCmd("lsblk", "-J", "-o", "NAME,TYPE,SIZE,MODEL,SERIAL,TRAN,ROTA,MOUNTPOINT")
-	if err == nil {
-		var lsblk lsblkOutput
-		if json.Unmarshal([]byte(out), &lsblk) == nil {
-			for _, dev := range lsblk.BlockDevices {
-				if dev.Type != "disk" {
-					continue
-				}
+	if err != nil || json.Unmarshal([]byte(out), &lsblk) != nil {
+		disks = detectDisksSysfs("/")
+	} else {
+		for _, dev := range lsblk.BlockDevices {
+			if dev.Type != "disk" {
+				continue
+			}
 
-				tran := ""
-				if dev.Tran != nil {
-					tran = strings.ToLower(*dev.Tran)
-				}
-				rota := true
-				if dev.Rota != nil {
-					rota = *dev.Rota
-				}
+			tran := ""
+			if dev.Tran != nil {
+				tran = strings.ToLower(*dev.Tran)
+			}
+			rota := true
+			if dev.Rota != nil {
+				rota = *dev.Rota
+			}
 
-				var diskType, iface string
-				switch {
-				case tran == "nvme" || strings.HasPrefix(dev.Name, "nvme"):
-					diskType, iface = "nvme", "PCIe/NVMe"
-				case tran == "usb":
-					diskType, iface = "usb", "USB"
-				case tran == "sata" || tran == "ata":
-					iface = "SATA"
-					if rota {
-						diskType = "hdd"
-					} else {
-						diskType = "ssd"
-					}
-				default:
-					if rota {
-						diskType = "hdd"
-					} else {
-						diskType = "ssd"
-					}
-					if tran != "" {
-						iface = strings.ToUpper(tran)
-					} else {
-						// Try to detect RAID controller or SAS via sysfs
-						iface = detectInterfaceSysfs(dev.Name)
-					}
-				}
+			diskType, iface := classifyLinuxDisk("/", dev.Name, tran, rota)
 
-				model := "Unknown"
-				if dev.Model != nil {
-					model = strings.TrimSpace(*dev.Model)
-				}
-				serial := ""
-				if dev.Serial != nil {
-					serial = *dev.Serial
-				}
+			model := "Unknown"
+			if dev.Model != nil {
+				model = strings.TrimSpace(*dev.Model)
+			}
+			serial := ""
+			if dev.Serial != nil {
+				serial = *dev.Serial
+			}
 
-				mount := ""
-				if dev.MountPoint != nil {
-					mount = *dev.MountPoint
-				}
-				if mount == "" {
-					for _, child := range dev.Children {
-						if child.MountPoint != nil && *child.MountPoint != "" {
-							mount = *child.MountPoint
-							break
-						}
+			mount := ""
+			if dev.MountPoint != nil {
+				mount = *dev.MountPoint
+			}
+			if mount == "" {
+				for _, child := range dev.Children {
+					if child.MountPoint != nil && *child.MountPoint != "" {
+						mount = *child.MountPoint
+						break
 					}
 				}
-
-				disks = append(disks, DiskInfo{
-					Device:     "/dev/" + dev.Name,
-					Name:       model,
-					DiskType:   diskType,
-					Interface:  iface,
-					SizeBytes:  parseLsblkSize(dev.Size),
-					MountPoint: mount,
-					Serial:     serial,
-				})
 			}
-		}
-	}
 
-	// NFS mounts
-	out, err = runCmd("mount", "-t", "nfs,nfs4")
-	if err == nil {
-		for _, line := range strings.Split(out, "\n") {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			parts := strings.SplitN(line, " on ", 2)
-			if len(parts) < 2 {
-				continue
-			}
-			remote := strings.TrimSpace(parts[0])
-			rest := strings.SplitN(parts[1], " type ", 2)
-			mpoint := strings.TrimSpace(rest[0])
 			disks = append(disks, DiskInfo{
-				Device: remote, Name: remote, DiskType: "nfs",
-				Interface: "Network", MountPoint: mpoint,
+				Device:     "/dev/" + dev.Name,
+				Name:       model,
+				DiskType:   diskType,
+				Interface:  iface,
+				SizeBytes:  parseLsblkSize(dev.Size),
+				MountPoint: mount,
+				Serial:     serial,
 			})
 		}
 	}
 
+	// Network filesystems and VM host shares
+	for _, m := range readMountinfo() {
+		k, ok := lookupFSKind(m.FSType)
+		if !ok || !k.Shared {
+			continue
+		}
+		disks = append(disks, DiskInfo{
+			Device: m.Source, Name: m.Source, DiskType: k.DiskType,
+			Interface: k.Interface, MountPoint: m.MountPoint, FSType: m.FSType,
+		})
+	}
+
 	return disks
 }
 
+// classifyLinuxDisk maps a transport (lsblk TRAN, or a sysfs-derived hint)
+// and the rotational flag to a disk type and interface label.
+func classifyLinuxDisk(root, name, tran string, rota bool) (diskType, iface string) {
+	switch {
+	case tran == "nvme" || strings.HasPrefix(name, "nvme"):
+		return "nvme", "PCIe/NVMe"
+	case tran == "usb":
+		return "usb", "USB"
+	case strings.HasPrefix(name, "zram"):
+		return "zram", "Memory/zram"
+	}
+	diskType = "ssd"
+	if rota {
+		diskType = "hdd"
+	}
+	switch {
+	case tran == "sata" || tran == "ata":
+		iface = "SATA"
+	case tran != "":
+		iface = strings.ToUpper(tran)
+	default:
+		// Try to detect RAID controller or SAS via sysfs
+		iface = detectInterfaceSysfs(root, name)
+	}
+	return diskType, iface
+}
+
 func matchDevicePlatform(device string, disks []DiskInfo) *DiskInfo {
-	// Linux: /dev/sda1 -> /dev/sda
-	return nil // Generic matching in detect.go handles this
+	// Linux: /dev/sda1 -> /dev/sda via sysfs, so filesystems on a whole
+	// disk match too and sdaa1 never matches sda.
+	if !strings.HasPrefix(device, "/dev/") {
+		return nil
+	}
+	parent := "/dev/" + sysfsDiskName(device)
+	for _, d := range disks {
+		if d.Device == parent {
+			copy := d
+			copy.Device = device
+			copy.MountPoint = ""
+			return &copy
+		}
+	}
+	return nil
 }
 
 func getPartitionSizePlatform(path string) int64 {
@@ -153,8 +160,8 @@ func getPartitionSizePlatform(path string) int64 {
 
 // detectInterfaceSysfs tries to determine the interface type from sysfs.
 // Useful for RAID controllers and SAS devices where lsblk TRAN is empty.
-func detectInterfaceSysfs(devName string) string {
-	sysPath := filepath.Join("/sys/block", devName, "device")
+func detectInterfaceSysfs(root, devName string) string {
+	sysPath := filepath.Join(root, "sys/block", devName, "device")
 
 	// Check vendor
 	vendor := readSysfsFile(filepath.Join(sysPath, "vendor"))
@@ -195,6 +202,50 @@ func detectInterfaceSysfs(devName string) string {
 	return "Unknown"
 }
 
+// scsiHostPlatform returns the SCSI host number a device hangs off, or -1.
+// Accepts /dev/bus/N (smartctl's MegaRAID alias), /dev/sdX and /dev/sgX.
+func scsiHostPlatform(device string) int {
+	if n, ok := strings.CutPrefix(device, "/dev/bus/"); ok {
+		if h, err := strconv.Atoi(n); err == nil {
+			return h
+		}
+		return -1
+	}
+
+	base := filepath.Base(device)
+	sysPath := filepath.Join("/sys/block", base, "device")
+	if strings.HasPrefix(base, "sg") {
+		sysPath = filepath.Join("/sys/class/scsi_generic", base, "device")
+	}
+	real, err := filepath.EvalSymlinks(sysPath)
+	if err != nil {
+		return -1
+	}
+	for _, part := range strings.Split(real, "/") {
+		if n, ok := strings.CutPrefix(part, "host"); ok {
+			if h, err := strconv.Atoi(n); err == nil {
+				return h
+			}
+		}
+	}
+	return -1
+}
+
+// sysfsDiskName maps a block device (/dev/sda1, /dev/nvme0n1p2) to the
+// name of the whole disk in /sys/block (sda, nvme0n1).
+func sysfsDiskName(device string) string {
+	base := filepath.Base(device)
+	sysPath := filepath.Join("/sys/class/block", base)
+	if _, err := os.Stat(filepath.Join(sysPath, "partition")); err != nil {
+		return base
+	}
+	real, err := filepath.EvalSymlinks(sysPath)
+	if err != nil {
+		return base
+	}
+	return filepath.Base(filepath.Dir(real))
+}
+
 func readSysfsFile(path string) string {
 	data, err := os.ReadFile(path)
 	if err != nil {
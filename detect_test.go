@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestGuessDiskType(t *testing.T) {
+	tests := []struct{ device, want string }{
+		{`C:\`, "unknown"},
+		{`D:\data`, "unknown"},
+		{"/dev/sda1", "unknown"},
+		{"/dev/nvme0n1p2", "nvme"},
+		{"/dev/zram0", "zram"},
+		{`\\fileserver\share`, "smb"},
+		{"//fileserver/share", "smb"},
+		{"nfs-server.local", "nfs"},
+		{"tmpfs", "tmpfs"},
+	}
+	for _, tt := range tests {
+		if got := guessDiskType(tt.device); got != tt.want {
+			t.Errorf("guessDiskType(%q) = %q, want %q", tt.device, got, tt.want)
+		}
+	}
+}
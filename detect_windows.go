@@ -111,8 +111,8 @@ func detectDisksPlatform() []DiskInfo {
 				remote := fields[2]
 				if strings.HasPrefix(remote, `\\`) {
 					disks = append(disks, DiskInfo{
-						Device: remote, Name: remote, DiskType: "nfs",
-						Interface: "Network", MountPoint: fields[1] + `\`,
+						Device: remote, Name: remote, DiskType: "smb",
+						Interface: "Network/SMB", MountPoint: fields[1] + `\`,
 					})
 				}
 			}
@@ -209,6 +209,10 @@ func matchDevicePlatform(device string, disks []DiskInfo) *DiskInfo {
 	return nil // Windows devices are direct PhysicalDrive paths
 }
 
+func scsiHostPlatform(device string) int {
+	return -1 // controller topology is only resolved on Linux
+}
+
 func getPartitionSizePlatform(path string) int64 {
 	pathp, err := syscall.UTF16PtrFromString(path)
 	if err != nil {
@@ -9,31 +9,50 @@ import (
 	"time"
 )
 
-func openDirectRead(path string) (*os.File, bool) {
+// oDSync makes every write durable before it returns, for --io-mode dsync.
+const oDSync = syscall.O_DSYNC
+
+func openDirectRead(path string) (*os.File, bool, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
-	// F_NOCACHE = 48
-	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), 48, 1)
-	ok := errno == 0
-	return f, ok
+	return noCache(f)
 }
 
-func openDirectWrite(path string) (*os.File, bool) {
+func openDirectWrite(path string) (*os.File, bool, error) {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
-	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), 48, 1)
-	ok := errno == 0
-	return f, ok
+	return noCache(f)
+}
+
+func openDirectRW(path string) (*os.File, bool, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	return noCache(f)
+}
+
+// noCache sets F_NOCACHE (48) on f; without it f stays usable, buffered.
+func noCache(f *os.File) (*os.File, bool, error) {
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), 48, 1); errno != 0 {
+		return f, false, errno
+	}
+	return f, true, nil
 }
 
-func alignedBuffer(size int) []byte {
+func alignedBuffer(size, align int) []byte {
 	return make([]byte, size) // macOS F_NOCACHE doesn't require alignment
 }
 
+// dioAlignment: F_NOCACHE works at any buffer address, offset and size.
+func dioAlignment(path string) dioAlign {
+	return dioAlign{Offset: 1, Source: "F_NOCACHE"}
+}
+
 func setNoCache(f *os.File) bool {
 	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), 48, 1)
 	return errno == 0
@@ -3,40 +3,134 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
+	"strconv"
 	"syscall"
 	"time"
 	"unsafe"
 )
 
-func openDirectRead(path string) (*os.File, bool) {
+// oDSync makes every write durable before it returns, for --io-mode dsync.
+const oDSync = syscall.O_DSYNC
+
+func openDirectRead(path string) (*os.File, bool, error) {
 	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_DIRECT, 0)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
-	return os.NewFile(uintptr(fd), path), true
+	return os.NewFile(uintptr(fd), path), true, nil
 }
 
-func openDirectWrite(path string) (*os.File, bool) {
+func openDirectWrite(path string) (*os.File, bool, error) {
 	fd, err := syscall.Open(path, syscall.O_WRONLY|syscall.O_CREAT|syscall.O_TRUNC|syscall.O_DIRECT, 0644)
 	if err != nil {
-		return nil, false
+		return nil, false, err
+	}
+	return os.NewFile(uintptr(fd), path), true, nil
+}
+
+func openDirectRW(path string) (*os.File, bool, error) {
+	fd, err := syscall.Open(path, syscall.O_RDWR|syscall.O_DIRECT, 0)
+	if err != nil {
+		return nil, false, err
 	}
-	return os.NewFile(uintptr(fd), path), true
+	return os.NewFile(uintptr(fd), path), true, nil
 }
 
-func alignedBuffer(size int) []byte {
-	const align = 4096
+// alignedBuffer returns a buffer whose address is a multiple of align
+// (4096 if align is 0).
+func alignedBuffer(size, align int) []byte {
+	if align <= 0 {
+		align = 4096
+	}
 	buf := make([]byte, size+align)
 	addr := uintptr(unsafe.Pointer(&buf[0]))
-	offset := int(align - (addr % uintptr(align)))
+	offset := int(uintptr(align) - addr%uintptr(align))
 	if offset == align {
 		offset = 0
 	}
 	return buf[offset : offset+size]
 }
 
+// statx(2) numbers by architecture; 0 where unknown, which skips statx.
+var sysStatx = map[string]uintptr{
+	"386": 383, "amd64": 332, "arm": 397, "arm64": 291, "loong64": 291, "riscv64": 291,
+	"mips": 4366, "mipsle": 4366, "mips64": 5326, "mips64le": 5326,
+	"ppc64": 383, "ppc64le": 383, "s390x": 379,
+}[runtime.GOARCH]
+
+const (
+	statxDIOAlign = 0x2000 // STATX_DIOALIGN, Linux 6.1
+	blkSSZGet     = 0x1268 // BLKSSZGET: logical sector size
+	blkPBSZGet    = 0x127b // BLKPBSZGET: physical sector size
+)
+
+// statxBuf is struct statx with only the fields read here.
+type statxBuf struct {
+	Mask           uint32
+	_              [148]byte
+	DIOMemAlign    uint32
+	DIOOffsetAlign uint32
+	_              [96]byte
+}
+
+// dioAlignment asks the kernel what direct I/O on path must be aligned to:
+// statx STATX_DIOALIGN for the file itself, else the logical sector size of
+// the block device it is on, from BLKSSZGET or sysfs.
+func dioAlignment(path string) dioAlign {
+	if sysStatx != 0 {
+		if p, err := syscall.BytePtrFromString(path); err == nil {
+			var stx statxBuf
+			dirfd := -100 // AT_FDCWD
+			_, _, errno := syscall.Syscall6(sysStatx, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+				0, statxDIOAlign, uintptr(unsafe.Pointer(&stx)), 0)
+			if errno == 0 && stx.Mask&statxDIOAlign != 0 {
+				return dioAlign{Mem: int(stx.DIOMemAlign), Offset: int(stx.DIOOffsetAlign), Source: "statx"}
+			}
+		}
+	}
+
+	var st syscall.Stat_t
+	if syscall.Stat(path, &st) != nil {
+		return dioAlign{Mem: 4096, Offset: 4096, Source: "default"}
+	}
+	dev := uint64(st.Dev)
+	major := uint32((dev>>8)&0xfff | (dev>>32)&^0xfff)
+	minor := uint32(dev&0xff | (dev>>12)&^0xff)
+	if major == 0 {
+		// Network and other virtual filesystems have no block device
+		return dioAlign{Mem: 4096, Offset: 4096, Source: "default"}
+	}
+	node := fmt.Sprintf("/dev/block/%d:%d", major, minor)
+	if fd, err := syscall.Open(node, syscall.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+		var logical, physical int32
+		_, _, e1 := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), blkSSZGet, uintptr(unsafe.Pointer(&logical)))
+		_, _, e2 := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), blkPBSZGet, uintptr(unsafe.Pointer(&physical)))
+		syscall.Close(fd)
+		if e1 == 0 && logical > 0 {
+			a := dioAlign{Mem: int(logical), Offset: int(logical), Source: "BLKSSZGET"}
+			if e2 == 0 {
+				a.Physical = int(physical)
+			}
+			return a
+		}
+	}
+
+	// Partitions keep their queue attributes in the parent disk
+	sys := fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)
+	for _, dir := range []string{sys + "/queue", sys + "/../queue"} {
+		if n, err := strconv.Atoi(readSysfsFile(dir + "/logical_block_size")); err == nil && n > 0 {
+			a := dioAlign{Mem: n, Offset: n, Source: "sysfs"}
+			a.Physical, _ = strconv.Atoi(readSysfsFile(dir + "/physical_block_size"))
+			return a
+		}
+	}
+	return dioAlign{Mem: 4096, Offset: 4096, Source: "default"}
+}
+
 func setNoCache(f *os.File) bool {
 	_ = f // unused; Linux uses O_DIRECT instead
 	return false
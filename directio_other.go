@@ -2,28 +2,48 @@
 
 package main
 
-import "os"
+import (
+	"errors"
+	"os"
+)
 
-func openDirectRead(path string) (*os.File, bool) {
+// oDSync makes every write durable before it returns, for --io-mode dsync.
+const oDSync = os.O_SYNC
+
+var errNoDirectIO = errors.New("not supported on this platform")
+
+func openDirectRead(path string) (*os.File, bool, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
-	return f, false // no direct I/O
+	return f, false, errNoDirectIO
 }
 
-func openDirectWrite(path string) (*os.File, bool) {
+func openDirectWrite(path string) (*os.File, bool, error) {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
-		return nil, false
+		return nil, false, err
+	}
+	return f, false, errNoDirectIO
+}
+
+func openDirectRW(path string) (*os.File, bool, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, false, err
 	}
-	return f, false
+	return f, false, errNoDirectIO
 }
 
-func alignedBuffer(size int) []byte {
+func alignedBuffer(size, align int) []byte {
 	return make([]byte, size)
 }
 
+func dioAlignment(path string) dioAlign {
+	return dioAlign{Offset: 1, Source: "none"}
+}
+
 func setNoCache(f *os.File) bool {
 	_ = f
 	return false
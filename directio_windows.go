@@ -4,6 +4,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"syscall"
 	"time"
 	"unsafe"
@@ -15,10 +16,14 @@ const (
 	fileFlagSequentialScan = 0x08000000
 )
 
-func openDirectRead(path string) (*os.File, bool) {
+// oDSync makes every write durable before it returns, for --io-mode dsync;
+// Go opens O_SYNC files with FILE_FLAG_WRITE_THROUGH.
+const oDSync = os.O_SYNC
+
+func openDirectRead(path string) (*os.File, bool, error) {
 	pathp, err := syscall.UTF16PtrFromString(path)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
 	h, err := syscall.CreateFile(
 		pathp,
@@ -29,15 +34,15 @@ func openDirectRead(path string) (*os.File, bool) {
 		fileFlagNoBuffering|fileFlagSequentialScan,
 		0)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
-	return os.NewFile(uintptr(h), path), true
+	return os.NewFile(uintptr(h), path), true, nil
 }
 
-func openDirectWrite(path string) (*os.File, bool) {
+func openDirectWrite(path string) (*os.File, bool, error) {
 	pathp, err := syscall.UTF16PtrFromString(path)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
 	h, err := syscall.CreateFile(
 		pathp,
@@ -48,22 +53,73 @@ func openDirectWrite(path string) (*os.File, bool) {
 		fileFlagNoBuffering|fileFlagWriteThrough,
 		0)
 	if err != nil {
-		return nil, false
+		return nil, false, err
+	}
+	return os.NewFile(uintptr(h), path), true, nil
+}
+
+func openDirectRW(path string) (*os.File, bool, error) {
+	pathp, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, false, err
+	}
+	h, err := syscall.CreateFile(
+		pathp,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE,
+		nil,
+		syscall.OPEN_EXISTING,
+		fileFlagNoBuffering|fileFlagWriteThrough,
+		0)
+	if err != nil {
+		return nil, false, err
 	}
-	return os.NewFile(uintptr(h), path), true
+	return os.NewFile(uintptr(h), path), true, nil
 }
 
-func alignedBuffer(size int) []byte {
-	const align = 4096
+// alignedBuffer returns a buffer whose address is a multiple of align
+// (4096 if align is 0).
+func alignedBuffer(size, align int) []byte {
+	if align <= 0 {
+		align = 4096
+	}
 	buf := make([]byte, size+align)
 	addr := uintptr(unsafe.Pointer(&buf[0]))
-	offset := int(align - (addr % uintptr(align)))
+	offset := int(uintptr(align) - addr%uintptr(align))
 	if offset == align {
 		offset = 0
 	}
 	return buf[offset : offset+size]
 }
 
+// dioAlignment returns the volume's sector size, which
+// FILE_FLAG_NO_BUFFERING requires of buffer addresses, offsets and sizes.
+func dioAlignment(path string) dioAlign {
+	a := dioAlign{Mem: 4096, Offset: 4096, Source: "default"}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return a
+	}
+	rootp, err := syscall.UTF16PtrFromString(filepath.VolumeName(abs) + `\`)
+	if err != nil {
+		return a
+	}
+	var sectorsPerCluster, bytesPerSector, freeClusters, totalClusters uint32
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	proc := kernel32.NewProc("GetDiskFreeSpaceW")
+	r, _, _ := proc.Call(
+		uintptr(unsafe.Pointer(rootp)),
+		uintptr(unsafe.Pointer(&sectorsPerCluster)),
+		uintptr(unsafe.Pointer(&bytesPerSector)),
+		uintptr(unsafe.Pointer(&freeClusters)),
+		uintptr(unsafe.Pointer(&totalClusters)),
+	)
+	if r == 0 || bytesPerSector == 0 {
+		return a
+	}
+	return dioAlign{Mem: int(bytesPerSector), Offset: int(bytesPerSector), Source: "GetDiskFreeSpace"}
+}
+
 func setNoCache(f *os.File) bool {
 	_ = f // unused; Windows uses CreateFile flags instead
 	return false
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deviceShareWarn is the share of the benchmark's bytes below which the
+// device is reported as having done much less work than was counted.
+const deviceShareWarn = 0.5
+
+// diskStat holds the cumulative counters of a block device's stat file.
+type diskStat struct {
+	readIOs, readSectors   int64
+	writeIOs, writeSectors int64
+	ioTicksMS, queueMS     int64
+}
+
+// parseDiskStat parses /sys/block/<dev>/stat, the same fields as a
+// /proc/diskstats line after the device name. Sectors are 512 bytes.
+func parseDiskStat(s string) (diskStat, bool) {
+	f := strings.Fields(s)
+	if len(f) < 11 {
+		return diskStat{}, false
+	}
+	n := func(i int) int64 {
+		v, _ := strconv.ParseInt(f[i], 10, 64)
+		return v
+	}
+	return diskStat{
+		readIOs: n(0), readSectors: n(2),
+		writeIOs: n(4), writeSectors: n(6),
+		ioTicksMS: n(9), queueMS: n(10),
+	}, true
+}
+
+// diskStatsMonitor samples the device's counters at every phase change of
+// the benchmarks it observes, then passes the calls on to next.
+type diskStatsMonitor struct {
+	next   benchObserver
+	file   string // stat file, "" when the device is unknown
+	result DeviceIOResult
+
+	mu     sync.Mutex
+	phase  string
+	mode   string
+	start  time.Time
+	before diskStat
+	bytes  atomic.Int64
+}
+
+// startDiskStats watches the block device under testDir. Without one (network
+// filesystems, tmpfs, other platforms) it only forwards to next.
+func startDiskStats(testDir string, next benchObserver) *diskStatsMonitor {
+	file, device := diskStatFile(testDir)
+	return &diskStatsMonitor{next: next, file: file, result: DeviceIOResult{Device: device}}
+}
+
+func (m *diskStatsMonitor) read() (diskStat, bool) {
+	data, err := os.ReadFile(m.file)
+	if err != nil {
+		return diskStat{}, false
+	}
+	return parseDiskStat(string(data))
+}
+
+// setPhase closes the running phase and starts the next; "" marks untimed
+// work such as dropping caches.
+func (m *diskStatsMonitor) setPhase(phase string) {
+	m.next.setPhase(phase)
+	if m.file == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closePhase()
+	if phase == "" {
+		return
+	}
+	if st, ok := m.read(); ok {
+		m.phase, m.mode, m.before, m.start = phase, benchIOMode, st, time.Now()
+		m.bytes.Store(0)
+	}
+}
+
+func (m *diskStatsMonitor) addBytes(n int) {
+	m.bytes.Add(int64(n))
+	m.next.addBytes(n)
+}
+
+// closePhase records the running phase. Called with mu held.
+func (m *diskStatsMonitor) closePhase() {
+	if m.phase == "" {
+		return
+	}
+	after, ok := m.read()
+	elapsed := time.Since(m.start)
+	phase := m.phase
+	m.phase = ""
+	if !ok || elapsed <= 0 {
+		return
+	}
+	ms := float64(elapsed.Milliseconds())
+	p := DeviceIOPhase{
+		Phase:        phase,
+		Mode:         m.mode,
+		Seconds:      elapsed.Seconds(),
+		CountedBytes: m.bytes.Load(),
+		ReadIOs:      after.readIOs - m.before.readIOs,
+		WriteIOs:     after.writeIOs - m.before.writeIOs,
+		ReadBytes:    (after.readSectors - m.before.readSectors) * 512,
+		WriteBytes:   (after.writeSectors - m.before.writeSectors) * 512,
+	}
+	if ms > 0 {
+		p.UtilPct = min(float64(after.ioTicksMS-m.before.ioTicksMS)/ms*100, 100)
+		p.AvgQueue = float64(after.queueMS-m.before.queueMS) / ms
+	}
+	m.result.Phases = append(m.result.Phases, p)
+}
+
+// finish closes the last phase and returns the counters, or nil if none
+// were recorded.
+func (m *diskStatsMonitor) finish() *DeviceIOResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closePhase()
+	if len(m.result.Phases) == 0 {
+		return nil
+	}
+	return &m.result
+}
+
+// deviceShortfall returns the share of the counted bytes the device moved
+// in the phase's direction, and whether that is low enough to report.
+func deviceShortfall(p DeviceIOPhase) (float64, bool) {
+	if p.CountedBytes <= 0 {
+		return 0, false
+	}
+	var dev int64
+	switch {
+	case strings.Contains(p.Phase, "Read"):
+		dev = p.ReadBytes
+	case strings.Contains(p.Phase, "Write"):
+		dev = p.WriteBytes
+	default:
+		dev = p.ReadBytes + p.WriteBytes
+	}
+	share := float64(dev) / float64(p.CountedBytes)
+	return share, share < deviceShareWarn
+}
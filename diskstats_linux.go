@@ -0,0 +1,28 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diskStatFile returns the sysfs stat file and name of the block device
+// holding dir. For device-mapper and md volumes it is the top device, which
+// sees the same requests the benchmark issues.
+func diskStatFile(dir string) (string, string) {
+	device, _ := resolveMountPlatform(dir)
+	if !strings.HasPrefix(device, "/dev/") {
+		return "", ""
+	}
+	if real, err := filepath.EvalSymlinks(device); err == nil {
+		device = real
+	}
+	name := filepath.Base(device)
+	file := filepath.Join("/sys/class/block", name, "stat")
+	if _, err := os.Stat(file); err != nil {
+		return "", ""
+	}
+	return file, name
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func diskStatFile(dir string) (string, string) {
+	return "", "" // block device counters are only read on Linux
+}
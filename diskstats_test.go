@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestParseDiskStat(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want diskStat
+		ok   bool
+	}{
+		{
+			// Linux 5.5+: 17 fields with discard and flush counters
+			"17 fields",
+			"  280531    73512 20446158   105648   441207   438104 29541208  1060384        0   555360  1233764    52043        0 211148736    54960    31862    12771\n",
+			diskStat{readIOs: 280531, readSectors: 20446158, writeIOs: 441207, writeSectors: 29541208, ioTicksMS: 555360, queueMS: 1233764},
+			true,
+		},
+		{
+			// before Linux 4.18: 11 fields
+			"11 fields",
+			"    9102      230   415214     5820    31477    12260  1393056    48824        0    22860    54640\n",
+			diskStat{readIOs: 9102, readSectors: 415214, writeIOs: 31477, writeSectors: 1393056, ioTicksMS: 22860, queueMS: 54640},
+			true,
+		},
+		{"short", "1 2 3 4 5", diskStat{}, false},
+		{"empty", "", diskStat{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDiskStat(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseDiskStat = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDeviceShortfall(t *testing.T) {
+	const mb = 1 << 20
+	tests := []struct {
+		name  string
+		phase DeviceIOPhase
+		share float64
+		low   bool
+	}{
+		{"direct read", DeviceIOPhase{Phase: "Sequential Read", CountedBytes: 64 * mb, ReadBytes: 64 * mb}, 1, false},
+		{"cached read", DeviceIOPhase{Phase: "Random Read QD4", CountedBytes: 400 * mb, ReadBytes: 4 * mb, WriteBytes: 300 * mb}, 0.01, true},
+		{"direct write", DeviceIOPhase{Phase: "Sequential Write", CountedBytes: 64 * mb, WriteBytes: 65 * mb}, 65.0 / 64, false},
+		{"buffered write", DeviceIOPhase{Phase: "Random Write QD1", CountedBytes: 100 * mb, WriteBytes: 10 * mb}, 0.1, true},
+		{"mixed", DeviceIOPhase{Phase: "Random Mixed", CountedBytes: 100 * mb, ReadBytes: 30 * mb, WriteBytes: 40 * mb}, 0.7, false},
+		{"mixed cached", DeviceIOPhase{Phase: "Random Mixed", CountedBytes: 100 * mb, ReadBytes: 10 * mb, WriteBytes: 20 * mb}, 0.3, true},
+		{"nothing counted", DeviceIOPhase{Phase: "Sequential Read", ReadBytes: mb}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			share, low := deviceShortfall(tt.phase)
+			if low != tt.low || share < tt.share-1e-9 || share > tt.share+1e-9 {
+				t.Errorf("deviceShortfall = %g, %v, want %g, %v", share, low, tt.share, tt.low)
+			}
+		})
+	}
+}
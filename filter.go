@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// diskFilter selects among detected disks (--type, --exclude,
+// --mounted-only, --min-size). Explicit targets are never filtered.
+type diskFilter struct {
+	Types       []string
+	Exclude     []string
+	MountedOnly bool
+	MinSize     int64
+}
+
+// parseDiskFilter builds a filter from the comma-separated flag values.
+func parseDiskFilter(types, exclude string, mountedOnly bool, minSize string) (diskFilter, error) {
+	f := diskFilter{MountedOnly: mountedOnly}
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			f.Types = append(f.Types, t)
+		}
+	}
+	for _, e := range strings.Split(exclude, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			f.Exclude = append(f.Exclude, e)
+		}
+	}
+	if minSize != "" {
+		f.MinSize = parseSize(minSize)
+		if f.MinSize <= 0 {
+			return f, fmt.Errorf("invalid --min-size %q", minSize)
+		}
+	}
+	return f, nil
+}
+
+// match reports whether a disk passes the filter. Types match the disk
+// type or interface (nvme, ssd, hdd, usb, nfs, raid, ...); exclusions match
+// the device, any by-id link, the serial or the mount point.
+func (f diskFilter) match(d DiskInfo) bool {
+	if len(f.Types) > 0 {
+		ok := false
+		for _, t := range f.Types {
+			if t == d.DiskType || t == strings.ToLower(d.Interface) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, e := range f.Exclude {
+		if excludes(e, d) {
+			return false
+		}
+	}
+	if f.MountedOnly && d.MountPoint == "" {
+		return false
+	}
+	if f.MinSize > 0 && d.SizeBytes < f.MinSize {
+		return false
+	}
+	return true
+}
+
+func excludes(e string, d DiskInfo) bool {
+	if e == d.Device || e == d.Serial || (d.MountPoint != "" && e == d.MountPoint) {
+		return true
+	}
+	for _, id := range d.IDs {
+		if e == id || e == filepath.Base(id) {
+			return true
+		}
+	}
+	// /dev/disk/by-*/... or /dev/mapper links to the same node.
+	if real, err := filepath.EvalSymlinks(e); err == nil {
+		if dev, err := filepath.EvalSymlinks(d.Device); err == nil && real == dev {
+			return true
+		}
+	}
+	return false
+}
+
+func (f diskFilter) apply(disks []DiskInfo) []DiskInfo {
+	var out []DiskInfo
+	for _, d := range disks {
+		if f.match(d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// selectDisks resolves the command-line targets, or with none, the
+// detected disks that pass the filter.
+func selectDisks(targets []string, f diskFilter) []DiskInfo {
+	if len(targets) == 0 {
+		return f.apply(detectDisks())
+	}
+	var disks []DiskInfo
+	for _, t := range targets {
+		disks = append(disks, resolveTarget(t)...)
+	}
+	return disks
+}
@@ -0,0 +1,73 @@
+package main
+
+import "strings"
+
+// fsKind classifies a network or virtual filesystem by mount type.
+type fsKind struct {
+	DiskType  string
+	Interface string
+	Shared    bool // data lives on another machine or the VM host; listed by --list
+}
+
+// fsKinds maps mount types (Linux mountinfo, macOS mount) to disk types.
+var fsKinds = map[string]fsKind{
+	"nfs":            {"nfs", "Network", true},
+	"nfs4":           {"nfs", "Network", true},
+	"cifs":           {"smb", "Network/SMB", true},
+	"smb3":           {"smb", "Network/SMB", true},
+	"smbfs":          {"smb", "Network/SMB", true},
+	"ceph":           {"cephfs", "Network/Ceph", true},
+	"fuse.ceph-fuse": {"cephfs", "Network/Ceph", true},
+	"glusterfs":      {"glusterfs", "Network/Gluster", true},
+	"fuse.glusterfs": {"glusterfs", "Network/Gluster", true},
+	"lustre":         {"lustre", "Network/Lustre", true},
+	"9p":             {"9p", "VM/9p", true},
+	"virtiofs":       {"virtiofs", "VM/virtiofs", true},
+	"fuse.sshfs":     {"fuse", "FUSE/sshfs", true},
+	"fuse.rclone":    {"fuse", "FUSE/rclone", true},
+	"fuse.s3fs":      {"fuse", "FUSE/s3fs", true},
+	"tmpfs":          {"tmpfs", "Memory/tmpfs", false},
+	"overlay":        {"overlay", "Overlay", false},
+}
+
+// lookupFSKind returns the kind for a mount type. Unlisted FUSE
+// filesystems are classified by their subtype but not listed.
+func lookupFSKind(fsType string) (fsKind, bool) {
+	if k, ok := fsKinds[fsType]; ok {
+		return k, true
+	}
+	if sub, ok := strings.CutPrefix(fsType, "fuse."); ok {
+		return fsKind{"fuse", "FUSE/" + sub, false}, true
+	}
+	return fsKind{}, false
+}
+
+// noDiskHealth is the health message for disk types with no drive that
+// SMART could be read from.
+var noDiskHealth = map[string]string{
+	"nfs":       "Network storage - SMART not applicable",
+	"smb":       "SMB network share - SMART not applicable",
+	"cephfs":    "Ceph filesystem - check cluster health with 'ceph health'",
+	"glusterfs": "GlusterFS volume - check brick health with 'gluster volume status'",
+	"lustre":    "Lustre filesystem - check OST/MDT health with 'lfs check servers'",
+	"9p":        "Folder shared from the VM host - check the host's disks",
+	"virtiofs":  "Folder shared from the VM host - check the host's disks",
+	"fuse":      "FUSE filesystem - no local disk to check",
+	"tmpfs":     "RAM-backed filesystem - no disk to check",
+	"overlay":   "Overlay filesystem - check the disk holding its upper directory",
+	"zram":      "Compressed RAM block device - no disk to check",
+}
+
+// isNetworkType reports whether I/O for the disk type crosses a network.
+func isNetworkType(diskType string) bool {
+	switch diskType {
+	case "nfs", "smb", "cephfs", "glusterfs", "lustre", "fuse":
+		return true
+	}
+	return false
+}
+
+// isMemoryType reports whether the disk type is backed by RAM.
+func isMemoryType(diskType string) bool {
+	return diskType == "tmpfs" || diskType == "zram"
+}
@@ -10,35 +10,96 @@ import (
 )
 
 func checkHealth(disk DiskInfo) HealthResult {
-	// NFS: return N/A immediately
-	if disk.DiskType == "nfs" {
-		return HealthResult{Status: "N/A", Message: "Network storage - SMART not applicable"}
+	// Network and virtual filesystems: no drive to query
+	if msg, ok := noDiskHealth[disk.DiskType]; ok {
+		return HealthResult{Status: "N/A", Message: msg}
+	}
+	// md / LVM / dm-crypt: SMART lives on the disks underneath.
+	if len(disk.Members) > 0 {
+		return checkStackHealth(disk)
 	}
 
-	// Find smartctl
 	smartctl := findExecutable("smartctl")
+	result := smartHealth(smartctl, disk)
+
+	// The kernel often logs I/O errors and resets long before SMART fails.
+	result.KernelErrors = scanKernelLog(disk.Device)
+	activeHealthPolicy.apply(&result, disk.DiskType)
+
+	// Hardware RAID: the logical disk hides the real drives, so query each
+	// physical disk through the controller and let the worst one win.
+	if smartctl != "" && disk.Interface == "RAID" {
+		result.Members = checkRAIDMembers(smartctl, disk.Device)
+		for i := range result.Members {
+			m := &result.Members[i]
+			activeHealthPolicy.apply(&m.Health, disk.DiskType)
+			result.Status = worseStatus(result.Status, m.Health.Status)
+		}
+	}
+	return result
+}
+
+// smartHealth reads SMART data for the disk, before policy evaluation.
+func smartHealth(smartctl string, disk DiskInfo) HealthResult {
 	if smartctl == "" {
+		// NVMe exposes its health log directly; no smartctl needed.
+		if disk.DiskType == "nvme" {
+			if result, err := nvmeHealthPlatform(disk.Device); err == nil {
+				return *result
+			}
+		}
 		return HealthResult{
 			Status:  "UNKNOWN",
 			Message: "smartctl not found. Install smartmontools for health data.",
 		}
 	}
+	return querySmart(smartctl, disk.Device, smartDeviceType(disk))
+}
 
-	// Try JSON output first
-	result := trySmartctlJSON(smartctl, disk.Device)
+// querySmart reads SMART data for one device, trying JSON output first.
+func querySmart(smartctl, device, devType string) HealthResult {
+	result := trySmartctlJSON(smartctl, device, devType)
+	if result == nil && devType == "sat" {
+		// Not every USB bridge speaks SAT; retry with autodetection.
+		devType = ""
+		result = trySmartctlJSON(smartctl, device, devType)
+	}
 	if result != nil {
 		return *result
 	}
 
 	// Fallback: text output
-	return trySmartctlText(smartctl, disk.Device)
+	return trySmartctlText(smartctl, device, devType)
+}
+
+// smartDeviceType returns the smartctl -d type needed to reach the disk.
+func smartDeviceType(disk DiskInfo) string {
+	if disk.DiskType == "usb" {
+		return "sat" // USB-SATA bridges need SCSI-to-ATA translation
+	}
+	return ""
+}
+
+// smartctlArgs builds a smartctl argument list, adding -d when needed.
+func smartctlArgs(device, devType string, opts ...string) []string {
+	args := append([]string{}, opts...)
+	if devType != "" {
+		args = append(args, "-d", devType)
+	}
+	return append(args, device)
 }
 
-func trySmartctlJSON(smartctl, device string) *HealthResult {
-	// Run smartctl -a -j <device>
+func trySmartctlJSON(smartctl, device, devType string) *HealthResult {
+	// Run smartctl -a -j [-d type] <device>
 	// NOTE: smartctl may return non-zero exit code even with valid output
-	cmd := exec.Command(smartctl, "-a", "-j", device)
+	cmd := exec.Command(smartctl, smartctlArgs(device, devType, "-a", "-j")...)
 	out, _ := cmd.Output()
+	return parseSmartctlJSON(out)
+}
+
+// parseSmartctlJSON decodes `smartctl -a -j` output. Returns nil when the
+// output is empty, not JSON, or smartctl could not open the device.
+func parseSmartctlJSON(out []byte) *HealthResult {
 	if len(out) == 0 {
 		return nil
 	}
@@ -48,16 +109,25 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 		return nil
 	}
 
+	// Exit status bit 1: device could not be opened (wrong -d type,
+	// missing permissions). There is nothing to report in that case.
+	if sc, ok := data["smartctl"].(map[string]interface{}); ok {
+		if es, ok := sc["exit_status"].(float64); ok && int(es)&2 != 0 {
+			if _, ok := data["smart_status"]; !ok {
+				return nil
+			}
+		}
+	}
+
 	result := &HealthResult{Status: "UNKNOWN"}
+	result.Model, _ = data["model_name"].(string)
+	result.Serial, _ = data["serial_number"].(string)
 
 	// SMART status
 	smartPassed := true
 	if ss, ok := data["smart_status"].(map[string]interface{}); ok {
 		if passed, ok := ss["passed"].(bool); ok {
 			smartPassed = passed
-			if !passed {
-				result.Status = "CRITICAL"
-			}
 		}
 	}
 
@@ -77,22 +147,13 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 			result.PowerOnHours = int(poh)
 		}
 		if pct, ok := nvme["percentage_used"].(float64); ok {
-			result.WearLevel = 100 - int(pct)
-			result.Attributes = append(result.Attributes,
-				HealthAttr{"Percentage Used", fmt.Sprintf("%d%%", int(pct)), attrStatus(pct, 80, 95)})
+			result.setPercentageUsed(int(pct))
+			result.Attributes = append(result.Attributes, HealthAttr{
+				Name: "Percentage Used", Value: fmt.Sprintf("%d%%", int(pct)), Status: "OK", Key: "percentage_used"})
 		}
 		if me, ok := nvme["media_errors"].(float64); ok {
 			result.MediaErrors = int(me)
 		}
-
-		// Determine status
-		if !smartPassed {
-			result.Status = "CRITICAL"
-		} else if result.MediaErrors > 0 {
-			result.Status = "WARNING"
-		} else if smartPassed {
-			result.Status = "HEALTHY"
-		}
 	}
 
 	// ATA/SATA attributes
@@ -128,6 +189,8 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 					result.PowerOnHours = int(rawVal)
 				case 5: // Reallocated_Sector_Ct
 					result.ReallocatedSectors = int(rawVal)
+				case 197: // Current_Pending_Sector
+					result.PendingSectors = int(rawVal)
 				case 177, 231, 233: // Wear_Leveling variants
 					if result.WearLevel == 0 {
 						result.WearLevel = value
@@ -137,35 +200,20 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 				// Add notable attributes to display
 				switch id {
 				case 5, 9, 177, 194, 196, 197, 198, 231, 233:
-					status := "OK"
-					if id == 5 && rawVal > 0 {
-						status = "WARN"
-					}
-					if id == 197 && rawVal > 0 {
-						status = "WARN"
-					}
-					result.Attributes = append(result.Attributes,
-						HealthAttr{name, fmt.Sprintf("%d", rawVal), status})
+					result.Attributes = append(result.Attributes, HealthAttr{
+						Name: name, Value: fmt.Sprintf("%d", rawVal), Status: "OK", Key: ataPolicyKeys[id]})
 				}
 			}
-
-			// Determine status for ATA
-			if !smartPassed {
-				result.Status = "CRITICAL"
-			} else if result.ReallocatedSectors > 0 {
-				result.Status = "WARNING"
-			} else if result.Temperature > 70 {
-				result.Status = "WARNING"
-			} else if smartPassed {
-				result.Status = "HEALTHY"
-			}
 		}
 	}
 
-	// Fallback: SMART passed but no NVMe/ATA attribute sections found
-	// (common with hardware RAID controllers that expose virtual disks)
-	if result.Status == "UNKNOWN" && smartPassed {
+	// Base status is the SMART self-assessment; the health policy escalates
+	// it from the individual attributes. This also covers hardware RAID
+	// controllers that expose virtual disks without attribute sections.
+	if smartPassed {
 		result.Status = "HEALTHY"
+	} else {
+		result.Status = "CRITICAL"
 	}
 
 	// Build standard attributes if not already populated
@@ -178,13 +226,9 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 			}
 		}
 		if !found {
-			status := "OK"
-			if result.Temperature > 70 {
-				status = "WARN"
-			}
 			// Prepend temperature
 			result.Attributes = append([]HealthAttr{
-				{"Temperature", fmt.Sprintf("%dC", result.Temperature), status},
+				{Name: "Temperature", Value: fmt.Sprintf("%dC", result.Temperature), Status: "OK", Key: "temperature"},
 			}, result.Attributes...)
 		}
 	}
@@ -197,8 +241,8 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 			}
 		}
 		if !found {
-			result.Attributes = append(result.Attributes,
-				HealthAttr{"Power On Hours", fmt.Sprintf("%d", result.PowerOnHours), "OK"})
+			result.Attributes = append(result.Attributes, HealthAttr{
+				Name: "Power On Hours", Value: fmt.Sprintf("%d", result.PowerOnHours), Status: "OK", Key: "power_on_hours"})
 		}
 	}
 	if result.WearLevel > 0 {
@@ -210,12 +254,8 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 			}
 		}
 		if !found {
-			status := "OK"
-			if result.WearLevel < 20 {
-				status = "WARN"
-			}
-			result.Attributes = append(result.Attributes,
-				HealthAttr{"Wear Level", fmt.Sprintf("%d%%", result.WearLevel), status})
+			result.Attributes = append(result.Attributes, HealthAttr{
+				Name: "Wear Level", Value: fmt.Sprintf("%d%%", result.WearLevel), Status: "OK", Key: "wear_level"})
 		}
 	}
 	if result.MediaErrors > 0 || result.Temperature > 0 {
@@ -227,12 +267,8 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 			}
 		}
 		if !found && result.MediaErrors > 0 {
-			status := "OK"
-			if result.MediaErrors > 0 {
-				status = "WARN"
-			}
-			result.Attributes = append(result.Attributes,
-				HealthAttr{"Media Errors", fmt.Sprintf("%d", result.MediaErrors), status})
+			result.Attributes = append(result.Attributes, HealthAttr{
+				Name: "Media Errors", Value: fmt.Sprintf("%d", result.MediaErrors), Status: "OK", Key: "media_errors"})
 		}
 	}
 
@@ -245,22 +281,45 @@ func trySmartctlJSON(smartctl, device string) *HealthResult {
 	return result
 }
 
-// attrStatus returns OK/WARN/FAIL based on threshold levels.
-func attrStatus(val float64, warnThreshold, failThreshold float64) string {
-	if val >= failThreshold {
-		return "FAIL"
+// worseStatus returns the more severe of two health statuses.
+func worseStatus(a, b string) string {
+	rank := func(s string) int {
+		switch s {
+		case "CRITICAL":
+			return 3
+		case "WARNING":
+			return 2
+		case "HEALTHY":
+			return 1
+		default: // UNKNOWN, N/A
+			return 0
+		}
 	}
-	if val >= warnThreshold {
-		return "WARN"
+	if rank(b) > rank(a) {
+		return b
 	}
-	return "OK"
+	return a
 }
 
-func trySmartctlText(smartctl, device string) HealthResult {
-	cmd := exec.Command(smartctl, "-a", device)
+// ataPolicyKeys maps ATA attribute IDs to health policy attribute keys.
+var ataPolicyKeys = map[int]string{
+	5:   "reallocated_sectors",
+	9:   "power_on_hours",
+	177: "wear_level",
+	194: "temperature",
+	197: "pending_sectors",
+	231: "wear_level",
+	233: "wear_level",
+}
+
+func trySmartctlText(smartctl, device, devType string) HealthResult {
+	cmd := exec.Command(smartctl, smartctlArgs(device, devType, "-a")...)
 	out, _ := cmd.Output()
-	text := string(out)
+	return parseSmartctlText(string(out))
+}
 
+// parseSmartctlText extracts what it can from plain `smartctl -a` output.
+func parseSmartctlText(text string) HealthResult {
 	result := HealthResult{Status: "UNKNOWN"}
 
 	// Check overall health
@@ -272,29 +331,96 @@ func trySmartctlText(smartctl, device string) HealthResult {
 		result.Message = "SMART self-assessment: FAILED"
 	}
 
-	// Extract temperature
-	re := regexp.MustCompile(`(?i)(?:Temperature_Celsius|Airflow_Temperature)\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+(\d+)`)
+	// Identity
+	re := regexp.MustCompile(`(?m)^(?:Device Model|Model Number|Product):\s+(.+)$`)
 	if m := re.FindStringSubmatch(text); len(m) > 1 {
-		if t, err := strconv.Atoi(m[1]); err == nil {
-			result.Temperature = t
-			status := "OK"
-			if t > 70 {
-				status = "WARN"
-			}
-			result.Attributes = append(result.Attributes,
-				HealthAttr{"Temperature", fmt.Sprintf("%dC", t), status})
-		}
+		result.Model = strings.TrimSpace(m[1])
+	}
+	re = regexp.MustCompile(`(?m)^Serial [Nn]umber:\s+(.+)$`)
+	if m := re.FindStringSubmatch(text); len(m) > 1 {
+		result.Serial = strings.TrimSpace(m[1])
+	}
+
+	// Extract temperature
+	t, ok := ataTextRaw(text, `Temperature_Celsius|Airflow_Temperature_Cel`)
+	if !ok {
+		t, ok = nvmeTextValue(text, "Temperature")
+	}
+	if ok {
+		result.Temperature = t
+		result.Attributes = append(result.Attributes, HealthAttr{
+			Name: "Temperature", Value: fmt.Sprintf("%dC", t), Status: "OK", Key: "temperature"})
 	}
 
 	// Extract power on hours
-	re = regexp.MustCompile(`(?i)Power_On_Hours\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+(\d+)`)
-	if m := re.FindStringSubmatch(text); len(m) > 1 {
-		if h, err := strconv.Atoi(m[1]); err == nil {
-			result.PowerOnHours = h
-			result.Attributes = append(result.Attributes,
-				HealthAttr{"Power On Hours", fmt.Sprintf("%d", h), "OK"})
-		}
+	h, ok := ataTextRaw(text, `Power_On_Hours`)
+	if !ok {
+		h, ok = nvmeTextValue(text, "Power On Hours")
+	}
+	if ok {
+		result.PowerOnHours = h
+		result.Attributes = append(result.Attributes, HealthAttr{
+			Name: "Power On Hours", Value: fmt.Sprintf("%d", h), Status: "OK", Key: "power_on_hours"})
+	}
+
+	// ATA sector counters
+	if v, ok := ataTextRaw(text, `Reallocated_Sector_Ct`); ok {
+		result.ReallocatedSectors = v
+		result.Attributes = append(result.Attributes, HealthAttr{
+			Name: "Reallocated_Sector_Ct", Value: fmt.Sprintf("%d", v), Status: "OK", Key: "reallocated_sectors"})
+	}
+	if v, ok := ataTextRaw(text, `Current_Pending_Sector`); ok {
+		result.PendingSectors = v
+		result.Attributes = append(result.Attributes, HealthAttr{
+			Name: "Current_Pending_Sector", Value: fmt.Sprintf("%d", v), Status: "OK", Key: "pending_sectors"})
+	}
+
+	// NVMe health log
+	if pct, ok := nvmeTextValue(text, "Percentage Used"); ok {
+		result.setPercentageUsed(pct)
+		result.Attributes = append(result.Attributes, HealthAttr{
+			Name: "Percentage Used", Value: fmt.Sprintf("%d%%", pct), Status: "OK", Key: "percentage_used"})
+	}
+	if me, ok := nvmeTextValue(text, "Media and Data Integrity Errors"); ok {
+		result.MediaErrors = me
+		result.Attributes = append(result.Attributes, HealthAttr{
+			Name: "Media Errors", Value: fmt.Sprintf("%d", me), Status: "OK", Key: "media_errors"})
 	}
 
 	return result
 }
+
+// setPercentageUsed records an NVMe drive's percentage used and the wear
+// level it implies. Drives past their rated endurance report more than 100.
+func (r *HealthResult) setPercentageUsed(pct int) {
+	r.PercentageUsed = &pct
+	r.WearLevel = 100 - pct
+	if r.WearLevel < 0 {
+		r.WearLevel = 0
+	}
+}
+
+// ataTextRaw returns the raw value of an ATA attribute row, e.g.
+//
+//	5 Reallocated_Sector_Ct   0x0033   100   100   010    Pre-fail  Always       -       0
+func ataTextRaw(text, namePattern string) (int, bool) {
+	re := regexp.MustCompile(`(?im)^\s*\d+\s+(?:` + namePattern + `)\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+\S+\s+(\d+)`)
+	m := re.FindStringSubmatch(text)
+	if len(m) < 2 {
+		return 0, false
+	}
+	v, err := strconv.Atoi(m[1])
+	return v, err == nil
+}
+
+// nvmeTextValue returns a numeric field from the NVMe health log section,
+// e.g. "Power On Hours:  1,773" or "Temperature:  34 Celsius".
+func nvmeTextValue(text, label string) (int, bool) {
+	re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(label) + `:\s+([\d,]+)`)
+	m := re.FindStringSubmatch(text)
+	if len(m) < 2 {
+		return 0, false
+	}
+	v, err := strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+	return v, err == nil
+}
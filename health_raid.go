@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// smartDevice is one entry from `smartctl --scan-open`.
+type smartDevice struct {
+	Device  string // e.g. /dev/bus/0, /dev/sda
+	DevType string // e.g. megaraid,0, cciss,1, sat
+}
+
+// raidDeviceTypes are the smartctl -d prefixes that address a physical disk
+// behind a hardware RAID controller.
+var raidDeviceTypes = []string{"megaraid,", "cciss,", "aacraid,"}
+
+// checkRAIDMembers queries SMART for every physical disk that smartctl can
+// reach through the controller owning the logical device.
+func checkRAIDMembers(smartctl, device string) []MemberHealth {
+	host := scsiHostPlatform(device)
+
+	var members []MemberHealth
+	for _, sd := range scanSmartDevices(smartctl) {
+		if !isRAIDDeviceType(sd.DevType) {
+			continue
+		}
+		// Only report drives on the same controller as the logical disk.
+		if h := scsiHostPlatform(sd.Device); host >= 0 && h >= 0 && h != host {
+			continue
+		}
+		members = append(members, MemberHealth{
+			Slot:   sd.DevType,
+			Health: querySmart(smartctl, sd.Device, sd.DevType),
+		})
+	}
+	return members
+}
+
+// scanSmartDevices runs `smartctl --scan-open` and parses the result.
+func scanSmartDevices(smartctl string) []smartDevice {
+	out, _ := exec.Command(smartctl, "--scan-open").Output()
+	return parseSmartScan(string(out))
+}
+
+// parseSmartScan parses lines such as:
+//
+//	/dev/bus/0 -d megaraid,0 # /dev/bus/0 [megaraid_disk_00], SCSI device
+func parseSmartScan(out string) []smartDevice {
+	var devices []smartDevice
+	for _, line := range strings.Split(out, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "-d" {
+			continue
+		}
+		devices = append(devices, smartDevice{Device: fields[0], DevType: fields[2]})
+	}
+	return devices
+}
+
+func isRAIDDeviceType(devType string) bool {
+	for _, p := range raidDeviceTypes {
+		if strings.HasPrefix(devType, p) {
+			return true
+		}
+	}
+	return false
+}
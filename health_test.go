@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readFixture returns a file from testdata.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseSmartDump(t *testing.T) {
+	tests := []struct {
+		file           string
+		diskType       string
+		status         string
+		model, serial  string
+		temperature    int
+		powerOnHours   int
+		wearLevel      int
+		percentageUsed int // -1: not reported
+		reallocated    int
+		pending        int
+		attrKeys       []string // policy keys that must be among the attributes
+	}{
+		{
+			file: "sata_ssd.json", diskType: "ssd", status: "HEALTHY",
+			model: "Samsung SSD 860 EVO 500GB", serial: "S3Z2NB0K812345X",
+			temperature: 34, powerOnHours: 31502, wearLevel: 91, percentageUsed: -1, reallocated: 3,
+			attrKeys: []string{"temperature", "reallocated_sectors", "power_on_hours", "wear_level", "pending_sectors"},
+		},
+		{
+			file: "nvme.json", diskType: "nvme", status: "HEALTHY",
+			model: "Samsung SSD 970 EVO Plus 1TB", serial: "S4EWNX0R123456A",
+			temperature: 41, powerOnHours: 7321, wearLevel: 97, percentageUsed: 3,
+			attrKeys: []string{"temperature", "percentage_used", "power_on_hours", "wear_level"},
+		},
+		{
+			// smartctl -d sat through a USB-SATA bridge
+			file: "usb_sat.json", diskType: "hdd", status: "HEALTHY",
+			model: "WDC WD20NMVW-11AV3S3", serial: "WD-WX21A75P1234",
+			temperature: 37, powerOnHours: 3288, percentageUsed: -1, pending: 2,
+			attrKeys: []string{"temperature", "reallocated_sectors", "power_on_hours", "pending_sectors"},
+		},
+		{
+			file: "sata_hdd.txt", diskType: "hdd", status: "HEALTHY",
+			model: "ST4000VN008-2DR166", serial: "ZGY8K2L1",
+			temperature: 38, powerOnHours: 44112, percentageUsed: -1, reallocated: 16, pending: 8,
+			attrKeys: []string{"temperature", "power_on_hours", "reallocated_sectors", "pending_sectors"},
+		},
+		{
+			// past its rated endurance: wear level bottoms out at 0
+			file: "nvme.txt", diskType: "nvme", status: "HEALTHY",
+			model: "WD_BLACK SN850X 2000GB", serial: "23154Y800123",
+			temperature: 47, powerOnHours: 12806, percentageUsed: 101,
+			attrKeys: []string{"temperature", "power_on_hours", "percentage_used", "media_errors"},
+		},
+		{
+			file: "usb_sat.txt", diskType: "ssd", status: "HEALTHY",
+			model: "CT1000MX500SSD1", serial: "2140E5A1B2C3",
+			temperature: 31, powerOnHours: 9871, percentageUsed: -1,
+			attrKeys: []string{"temperature", "power_on_hours", "reallocated_sectors", "pending_sectors"},
+		},
+		{
+			// the bridge rejected SAT passthrough
+			file: "usb_open_failed.json", status: "UNKNOWN", percentageUsed: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			r, diskType := parseSmartDump(readFixture(t, filepath.Join("smartctl", tt.file)))
+			if diskType != tt.diskType {
+				t.Errorf("disk type = %q, want %q", diskType, tt.diskType)
+			}
+			if r.Status != tt.status {
+				t.Errorf("Status = %q, want %q", r.Status, tt.status)
+			}
+			if r.Model != tt.model || r.Serial != tt.serial {
+				t.Errorf("Model, Serial = %q, %q, want %q, %q", r.Model, r.Serial, tt.model, tt.serial)
+			}
+			if r.Temperature != tt.temperature {
+				t.Errorf("Temperature = %d, want %d", r.Temperature, tt.temperature)
+			}
+			if r.PowerOnHours != tt.powerOnHours {
+				t.Errorf("PowerOnHours = %d, want %d", r.PowerOnHours, tt.powerOnHours)
+			}
+			if r.WearLevel != tt.wearLevel {
+				t.Errorf("WearLevel = %d, want %d", r.WearLevel, tt.wearLevel)
+			}
+			pct := -1
+			if r.PercentageUsed != nil {
+				pct = *r.PercentageUsed
+			}
+			if pct != tt.percentageUsed {
+				t.Errorf("PercentageUsed = %d, want %d", pct, tt.percentageUsed)
+			}
+			if r.ReallocatedSectors != tt.reallocated || r.PendingSectors != tt.pending {
+				t.Errorf("reallocated, pending = %d, %d, want %d, %d",
+					r.ReallocatedSectors, r.PendingSectors, tt.reallocated, tt.pending)
+			}
+			keys := make(map[string]bool)
+			for _, a := range r.Attributes {
+				keys[a.Key] = true
+			}
+			for _, k := range tt.attrKeys {
+				if !keys[k] {
+					t.Errorf("no attribute with key %q in %+v", k, r.Attributes)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSmartctlJSONOpenFailure(t *testing.T) {
+	if r := parseSmartctlJSON(readFixture(t, "smartctl/usb_open_failed.json")); r != nil {
+		t.Errorf("parseSmartctlJSON = %+v, want nil", r)
+	}
+	if r := parseSmartctlJSON(nil); r != nil {
+		t.Errorf("parseSmartctlJSON(nil) = %+v, want nil", r)
+	}
+}
+
+func TestPolicyGradesWornOutNVMe(t *testing.T) {
+	r, diskType := parseSmartDump(readFixture(t, "smartctl/nvme.txt"))
+	defaultHealthPolicy().apply(&r, diskType)
+	for _, a := range r.Attributes {
+		if a.Key == "percentage_used" {
+			if a.Status != "FAIL" {
+				t.Errorf("percentage_used graded %q, want FAIL", a.Status)
+			}
+			return
+		}
+	}
+	t.Error("no percentage_used attribute")
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyEntry is one recorded health check, stored one JSON object per
+// line in <history dir>/<serial>.jsonl.
+type historyEntry struct {
+	Time               time.Time `json:"time"`
+	Device             string    `json:"device"`
+	Model              string    `json:"model,omitempty"`
+	Serial             string    `json:"serial"`
+	Status             string    `json:"status"`
+	Temperature        int       `json:"temperature"`
+	PowerOnHours       int       `json:"power_on_hours"`
+	WearLevel          int       `json:"wear_level"`
+	ReallocatedSectors int       `json:"reallocated_sectors"`
+	PendingSectors     int       `json:"pending_sectors"`
+	MediaErrors        int       `json:"media_errors"`
+}
+
+// trendAlert reports an attribute that worsened faster than its trend rule allows.
+type trendAlert struct {
+	Serial string
+	Model  string
+	Device string
+	Key    string
+	From   int
+	To     int
+	Span   time.Duration
+	Rule   trendRule
+}
+
+// trendKeys are the history attributes shown in the trend view.
+var trendKeys = []string{"reallocated_sectors", "pending_sectors", "media_errors", "wear_level", "temperature"}
+
+// defaultHistoryDir returns the per-user history location.
+func defaultHistoryDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "diskbench", "history")
+}
+
+// historyFile returns the store path for a disk serial.
+func historyFile(dir, serial string) string {
+	return filepath.Join(dir, safeFileName(serial)+".jsonl")
+}
+
+// safeFileName replaces the characters of a serial number that cannot
+// appear in a file name.
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r <= ' ' {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// healthSerial returns the serial a result is keyed by, or "".
+func healthSerial(disk DiskInfo, r HealthResult) string {
+	if r.Serial != "" {
+		return strings.TrimSpace(r.Serial)
+	}
+	return strings.TrimSpace(disk.Serial)
+}
+
+// recordHealth appends a health result to the history store.
+func recordHealth(dir, device, serial string, r HealthResult, now time.Time) error {
+	if serial == "" {
+		return fmt.Errorf("%s: no serial number, cannot track history", device)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(historyEntry{
+		Time: now.UTC(), Device: device, Model: r.Model, Serial: serial, Status: r.Status,
+		Temperature: r.Temperature, PowerOnHours: r.PowerOnHours, WearLevel: r.WearLevel,
+		ReallocatedSectors: r.ReallocatedSectors, PendingSectors: r.PendingSectors,
+		MediaErrors: r.MediaErrors,
+	})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(historyFile(dir, serial), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// loadHistory reads all entries for a serial, oldest first.
+// Malformed lines are skipped so a truncated write doesn't lose the history.
+func loadHistory(dir, serial string) ([]historyEntry, error) {
+	f, err := os.Open(historyFile(dir, serial))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e historyEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, sc.Err()
+}
+
+// historyMetric returns a trend attribute and whether it was reported.
+func historyMetric(e historyEntry, key string) (int, bool) {
+	switch key {
+	case "temperature":
+		return e.Temperature, e.Temperature > 0
+	case "wear_level":
+		return e.WearLevel, e.WearLevel > 0
+	case "reallocated_sectors":
+		return e.ReallocatedSectors, true
+	case "pending_sectors":
+		return e.PendingSectors, true
+	case "media_errors":
+		return e.MediaErrors, true
+	case "power_on_hours":
+		return e.PowerOnHours, e.PowerOnHours > 0
+	}
+	return 0, false
+}
+
+// trendAlerts compares the latest entry with the oldest one inside each
+// rule's window and reports attributes that worsened by at least rule.Warn.
+func trendAlerts(entries []historyEntry, rules map[string]trendRule) []trendAlert {
+	if len(entries) < 2 {
+		return nil
+	}
+	latest := entries[len(entries)-1]
+
+	var alerts []trendAlert
+	for _, key := range policyKeys {
+		rule, ok := rules[key]
+		if !ok {
+			continue
+		}
+		window, err := parseDuration(rule.Window)
+		if err != nil {
+			continue
+		}
+		to, ok := historyMetric(latest, key)
+		if !ok {
+			continue
+		}
+		for _, e := range entries[:len(entries)-1] {
+			if latest.Time.Sub(e.Time) > window {
+				continue
+			}
+			from, ok := historyMetric(e, key)
+			if !ok {
+				continue
+			}
+			delta := to - from
+			if key == "wear_level" {
+				delta = from - to // remaining life only goes down
+			}
+			if float64(delta) >= rule.Warn {
+				alerts = append(alerts, trendAlert{
+					Serial: latest.Serial, Model: latest.Model, Device: latest.Device,
+					Key: key, From: from, To: to, Span: latest.Time.Sub(e.Time), Rule: rule,
+				})
+			}
+			break // oldest entry in the window is the baseline
+		}
+	}
+	return alerts
+}
+
+// printTrendReport shows how the tracked attributes changed over the history.
+func printTrendReport(entries []historyEntry, alerts []trendAlert) {
+	if len(entries) == 0 {
+		return
+	}
+	first, last := entries[0], entries[len(entries)-1]
+	fmt.Printf("  %sHealth history: %d samples, %s to %s%s\n", colorDim, len(entries),
+		first.Time.Local().Format("2006-01-02 15:04"), last.Time.Local().Format("2006-01-02 15:04"), colorReset)
+	if len(entries) < 2 {
+		fmt.Printf("  %sNot enough history for trends yet.%s\n", colorDim, colorReset)
+		return
+	}
+
+	headers := []string{"Attribute", "First", "Min", "Max", "Latest", "Change"}
+	aligns := []byte{'l', 'r', 'r', 'r', 'r', 'r'}
+	var rows [][]string
+	for _, key := range trendKeys {
+		var vals []int
+		for _, e := range entries {
+			if v, ok := historyMetric(e, key); ok {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) == 0 {
+			continue
+		}
+		lo, hi := vals[0], vals[0]
+		for _, v := range vals {
+			lo = min(lo, v)
+			hi = max(hi, v)
+		}
+		change := fmt.Sprintf("%+d", vals[len(vals)-1]-vals[0])
+		for _, a := range alerts {
+			if a.Key == key {
+				change = colorRed + change + colorReset
+			}
+		}
+		rows = append(rows, []string{key, fmt.Sprint(vals[0]), fmt.Sprint(lo), fmt.Sprint(hi),
+			fmt.Sprint(vals[len(vals)-1]), change})
+	}
+	printTable(headers, rows, aligns)
+
+	for _, a := range alerts {
+		fmt.Printf("  %sTrend alert: %s%s\n", colorRed, a.describe(), colorReset)
+	}
+}
+
+// describe formats an alert as a single line.
+func (a trendAlert) describe() string {
+	return fmt.Sprintf("%s %+d (%d -> %d) in %s, threshold %g per %s",
+		a.Key, a.To-a.From, a.From, a.To, formatDuration(a.Span), a.Rule.Warn, a.Rule.Window)
+}
+
+// printTrendAlerts prints one line per alert, for cron-style output.
+func printTrendAlerts(alerts []trendAlert) {
+	for _, a := range alerts {
+		model := ""
+		if a.Model != "" {
+			model = " " + a.Model
+		}
+		fmt.Printf("%s%s serial %s: %s\n", a.Device, model, a.Serial, a.describe())
+	}
+}
+
+// diskTrend is the history of one physical disk after recording a check.
+type diskTrend struct {
+	Label   string // device, or RAID slot for disks behind a controller
+	Serial  string
+	Entries []historyEntry
+	Alerts  []trendAlert
+}
+
+// hasSmartData reports whether a health result holds real SMART readings.
+// UNKNOWN (smartctl failed, no permission) and N/A results carry zero
+// counters that would read as a jump at the next good reading.
+func hasSmartData(r HealthResult) bool {
+	return r.Status != "UNKNOWN" && r.Status != "N/A"
+}
+
+// trackHealth records a health result, including any RAID members, and
+// returns the resulting history and trend alerts for each physical disk.
+// Results without SMART data are neither recorded nor shown.
+func trackHealth(dir string, disk DiskInfo, r HealthResult) []diskTrend {
+	type sample struct {
+		label, serial string
+		health        HealthResult
+	}
+	var samples []sample
+	if hasSmartData(r) {
+		samples = append(samples, sample{disk.Device, healthSerial(disk, r), r})
+	}
+	for _, m := range r.Members {
+		if hasSmartData(m.Health) {
+			samples = append(samples, sample{disk.Device + " " + m.Slot, strings.TrimSpace(m.Health.Serial), m.Health})
+		}
+	}
+
+	now := time.Now()
+	var trends []diskTrend
+	for _, s := range samples {
+		t := diskTrend{Label: s.label, Serial: s.serial}
+		if err := recordHealth(dir, s.label, s.serial, s.health, now); err != nil {
+			if s.serial != "" {
+				fmt.Fprintf(os.Stderr, "  Warning: health history: %v\n", err)
+			}
+			trends = append(trends, t)
+			continue
+		}
+		t.Entries, _ = loadHistory(dir, s.serial)
+		t.Alerts = trendAlerts(t.Entries, activeHealthPolicy.Trends)
+		trends = append(trends, t)
+	}
+	return trends
+}
+
+// printDiskTrends prints the trend view for each disk returned by trackHealth.
+func printDiskTrends(trends []diskTrend) {
+	for _, t := range trends {
+		fmt.Printf("  %sTrend: %s%s\n", colorBold, t.Label, colorReset)
+		if t.Serial == "" {
+			fmt.Printf("  %sNo serial number reported; history not tracked.%s\n\n", colorDim, colorReset)
+			continue
+		}
+		printTrendReport(t.Entries, t.Alerts)
+		fmt.Println()
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrendAlerts(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	entry := func(age time.Duration, realloc, wear, temp int) historyEntry {
+		return historyEntry{Time: now.Add(-age), Device: "/dev/sda", Serial: "S1", Model: "Test SSD",
+			ReallocatedSectors: realloc, WearLevel: wear, Temperature: temp}
+	}
+	rules := map[string]trendRule{
+		"reallocated_sectors": {Warn: 5, Window: "7d"},
+		"wear_level":          {Warn: 5, Window: "30d"},
+		"temperature":         {Warn: 15, Window: "7d"},
+	}
+
+	tests := []struct {
+		name    string
+		entries []historyEntry
+		want    []trendAlert // Key, From, To and Span are compared
+	}{
+		{
+			name:    "single entry",
+			entries: []historyEntry{entry(0, 50, 90, 40)},
+		},
+		{
+			name:    "jump inside window",
+			entries: []historyEntry{entry(3*day, 0, 90, 40), entry(0, 8, 90, 40)},
+			want:    []trendAlert{{Key: "reallocated_sectors", From: 0, To: 8, Span: 3 * day}},
+		},
+		{
+			// the jump happened before the window; inside it nothing changed
+			name:    "jump outside window",
+			entries: []historyEntry{entry(10*day, 0, 90, 40), entry(6*day, 8, 90, 40), entry(0, 9, 90, 40)},
+		},
+		{
+			// the oldest entry in the window is the baseline, not the newest
+			name:    "oldest entry in window is baseline",
+			entries: []historyEntry{entry(6*day, 2, 90, 40), entry(1*day, 6, 90, 40), entry(0, 7, 90, 40)},
+			want:    []trendAlert{{Key: "reallocated_sectors", From: 2, To: 7, Span: 6 * day}},
+		},
+		{
+			// wear level is remaining life: a drop alerts, a rise does not
+			name:    "wear level drop",
+			entries: []historyEntry{entry(20*day, 0, 95, 40), entry(0, 0, 88, 40)},
+			want:    []trendAlert{{Key: "wear_level", From: 95, To: 88, Span: 20 * day}},
+		},
+		{
+			name:    "wear level rise",
+			entries: []historyEntry{entry(20*day, 0, 80, 40), entry(0, 0, 95, 40)},
+		},
+		{
+			name:    "wear level drop outside window",
+			entries: []historyEntry{entry(40*day, 0, 95, 40), entry(0, 0, 88, 40)},
+		},
+		{
+			// unreported temperatures (0) are not a baseline
+			name:    "temperature without baseline",
+			entries: []historyEntry{entry(2*day, 0, 90, 0), entry(1*day, 0, 90, 35), entry(0, 0, 90, 52)},
+			want:    []trendAlert{{Key: "temperature", From: 35, To: 52, Span: day}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trendAlerts(tt.entries, rules)
+			if len(got) != len(tt.want) {
+				t.Fatalf("trendAlerts = %+v, want %d alerts", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Key != w.Key || g.From != w.From || g.To != w.To || g.Span != w.Span {
+					t.Errorf("alert %d = %s %d->%d over %s, want %s %d->%d over %s",
+						i, g.Key, g.From, g.To, g.Span, w.Key, w.From, w.To, w.Span)
+				}
+				if g.Serial != "S1" || g.Device != "/dev/sda" {
+					t.Errorf("alert %d for %s %s, want S1 /dev/sda", i, g.Serial, g.Device)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// diskIDsPlatform returns the /dev/disk/by-id links for a whole disk,
+// which survive reboots and controller reordering unlike /dev/sdX.
+func diskIDsPlatform(device string) []string {
+	var ids []string
+	for _, link := range diskLinks("/dev/disk/by-id", device) {
+		if !strings.Contains(filepath.Base(link), "-part") {
+			ids = append(ids, link)
+		}
+	}
+	return ids
+}
+
+// diskSlotPlatform returns the /dev/disk/by-path name for a disk: its
+// physical location (controller and port), independent of what is in it.
+func diskSlotPlatform(device string) string {
+	for _, link := range diskLinks("/dev/disk/by-path", device) {
+		if name := filepath.Base(link); !strings.Contains(name, "-part") {
+			return name
+		}
+	}
+	return ""
+}
+
+// diskLinks returns the symlinks in dir that resolve to device, sorted.
+func diskLinks(dir, device string) []string {
+	target, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var links []string
+	for _, e := range entries {
+		link := filepath.Join(dir, e.Name())
+		if real, err := filepath.EvalSymlinks(link); err == nil && real == target {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+	return links
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+func diskIDsPlatform(device string) []string {
+	return nil // /dev/disk/by-id is udev-specific
+}
+
+func diskSlotPlatform(device string) string {
+	return ""
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// inventory is a snapshot of the physical disks in a machine, saved with
+// --inventory-save and compared with --inventory-diff.
+type inventory struct {
+	Time  time.Time       `json:"time"`
+	Host  string          `json:"host"`
+	Disks []inventoryDisk `json:"disks"`
+}
+
+type inventoryDisk struct {
+	Device    string   `json:"device"`
+	Slot      string   `json:"slot,omitempty"`
+	Model     string   `json:"model"`
+	Serial    string   `json:"serial,omitempty"`
+	WWN       string   `json:"wwn,omitempty"`
+	Firmware  string   `json:"firmware,omitempty"`
+	SizeBytes int64    `json:"size_bytes"`
+	IDs       []string `json:"ids,omitempty"`
+}
+
+// inventoryChange is one difference between two snapshots.
+type inventoryChange struct {
+	Kind   string // added, removed, replaced, firmware, moved
+	Disk   inventoryDisk
+	Detail string
+}
+
+// key identifies a disk across reboots: serial, then WWN, then slot.
+// The device name is the last resort and is not stable.
+func (d inventoryDisk) key() string {
+	switch {
+	case d.Serial != "":
+		return "serial:" + d.Serial
+	case d.WWN != "":
+		return "wwn:" + d.WWN
+	case d.Slot != "":
+		return "slot:" + d.Slot
+	}
+	return "device:" + d.Device
+}
+
+// takeInventory records the physical disks; network and virtual
+// filesystems are left out.
+func takeInventory(disks []DiskInfo) inventory {
+	host, _ := os.Hostname()
+	inv := inventory{Time: time.Now().UTC(), Host: host}
+	for _, d := range disks {
+		if _, ok := noDiskHealth[d.DiskType]; ok {
+			continue
+		}
+		item := inventoryDisk{
+			Device: d.Device, Slot: d.Slot, Model: d.Name, Serial: d.Serial,
+			SizeBytes: d.SizeBytes, IDs: d.IDs,
+		}
+		if d.Props != nil {
+			item.WWN = d.Props.WWN
+			item.Firmware = d.Props.Firmware
+		}
+		inv.Disks = append(inv.Disks, item)
+	}
+	return inv
+}
+
+func saveInventory(file string, inv inventory) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+func loadInventory(file string) (inventory, error) {
+	var inv inventory
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return inv, err
+	}
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return inv, fmt.Errorf("%s: %w", file, err)
+	}
+	return inv, nil
+}
+
+// diffInventory reports disks added, removed, replaced (a different disk
+// in the same slot), moved to another slot, or with new firmware.
+func diffInventory(old, cur inventory) []inventoryChange {
+	curByKey := make(map[string]inventoryDisk)
+	for _, d := range cur.Disks {
+		curByKey[d.key()] = d
+	}
+	oldKeys := make(map[string]bool)
+	for _, d := range old.Disks {
+		oldKeys[d.key()] = true
+	}
+
+	var changes []inventoryChange
+	usedSlots := make(map[string]bool)
+	for _, o := range old.Disks {
+		if c, ok := curByKey[o.key()]; ok {
+			if o.Firmware != "" && c.Firmware != "" && o.Firmware != c.Firmware {
+				changes = append(changes, inventoryChange{"firmware", c, o.Firmware + " -> " + c.Firmware})
+			}
+			if o.Slot != "" && c.Slot != "" && o.Slot != c.Slot {
+				changes = append(changes, inventoryChange{"moved", c, "from " + o.Slot})
+			}
+			continue
+		}
+		// Gone: was something else put in its slot?
+		replaced := false
+		if o.Slot != "" {
+			for _, c := range cur.Disks {
+				if c.Slot == o.Slot && !oldKeys[c.key()] {
+					changes = append(changes, inventoryChange{"replaced", c,
+						fmt.Sprintf("was %s %s", o.Model, o.Serial)})
+					usedSlots[c.Slot] = true
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			changes = append(changes, inventoryChange{"removed", o, "last seen as " + o.Device})
+		}
+	}
+	for _, c := range cur.Disks {
+		if !oldKeys[c.key()] && !(c.Slot != "" && usedSlots[c.Slot]) {
+			changes = append(changes, inventoryChange{"added", c, ""})
+		}
+	}
+	return changes
+}
+
+// printInventoryDiff prints the changes since a snapshot.
+func printInventoryDiff(old inventory, changes []inventoryChange) {
+	fmt.Printf("  %sInventory changes since %s (%s)%s\n", colorBold,
+		old.Time.Local().Format("2006-01-02 15:04"), old.Host, colorReset)
+	if len(changes) == 0 {
+		fmt.Printf("  %sNo changes: same disks, slots and firmware.%s\n", colorGreen, colorReset)
+		return
+	}
+	headers := []string{"Change", "Device", "Slot", "Model", "Serial", "Detail"}
+	aligns := []byte{'l', 'l', 'l', 'l', 'l', 'l'}
+	rows := make([][]string, len(changes))
+	for i, c := range changes {
+		color := colorYellow
+		switch c.Kind {
+		case "added":
+			color = colorGreen
+		case "removed":
+			color = colorRed
+		}
+		rows[i] = []string{color + c.Kind + colorReset, c.Disk.Device, c.Disk.Slot,
+			c.Disk.Model, c.Disk.Serial, c.Detail}
+	}
+	printTable(headers, rows, aligns)
+}
@@ -0,0 +1,93 @@
+package main
+
+import "testing"
+
+func TestDiffInventory(t *testing.T) {
+	disk := func(dev, slot, serial, fw string) inventoryDisk {
+		return inventoryDisk{Device: dev, Slot: slot, Model: "ST4000NM0035", Serial: serial, Firmware: fw}
+	}
+	tests := []struct {
+		name     string
+		old, cur []inventoryDisk
+		want     []inventoryChange // Kind, Disk.Serial and Detail are compared
+	}{
+		{
+			name: "unchanged, device renamed",
+			old:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sdb", "enc0/slot1", "ZC1", "TN04")},
+		},
+		{
+			name: "added",
+			old:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04"), disk("/dev/sdb", "enc0/slot2", "ZC2", "TN04")},
+			want: []inventoryChange{{Kind: "added", Disk: inventoryDisk{Serial: "ZC2"}}},
+		},
+		{
+			name: "removed",
+			old:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04"), disk("/dev/sdb", "enc0/slot2", "ZC2", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04")},
+			want: []inventoryChange{{Kind: "removed", Disk: inventoryDisk{Serial: "ZC2"}, Detail: "last seen as /dev/sdb"}},
+		},
+		{
+			// a different disk in the same slot is a replacement, not also an addition
+			name: "replaced in same slot",
+			old:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC9", "TN04")},
+			want: []inventoryChange{{Kind: "replaced", Disk: inventoryDisk{Serial: "ZC9"}, Detail: "was ST4000NM0035 ZC1"}},
+		},
+		{
+			name: "moved",
+			old:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sdc", "enc0/slot3", "ZC1", "TN04")},
+			want: []inventoryChange{{Kind: "moved", Disk: inventoryDisk{Serial: "ZC1"}, Detail: "from enc0/slot1"}},
+		},
+		{
+			name: "firmware",
+			old:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sda", "enc0/slot1", "ZC1", "TN05")},
+			want: []inventoryChange{{Kind: "firmware", Disk: inventoryDisk{Serial: "ZC1"}, Detail: "TN04 -> TN05"}},
+		},
+		{
+			// without a slot a vanished disk and a new one are not a replacement
+			name: "no slot: removed and added",
+			old:  []inventoryDisk{disk("/dev/sda", "", "ZC1", "TN04")},
+			cur:  []inventoryDisk{disk("/dev/sda", "", "ZC9", "TN04")},
+			want: []inventoryChange{
+				{Kind: "removed", Disk: inventoryDisk{Serial: "ZC1"}, Detail: "last seen as /dev/sda"},
+				{Kind: "added", Disk: inventoryDisk{Serial: "ZC9"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffInventory(inventory{Disks: tt.old}, inventory{Disks: tt.cur})
+			if len(got) != len(tt.want) {
+				t.Fatalf("diffInventory = %+v, want %d changes", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Kind != w.Kind || g.Disk.Serial != w.Disk.Serial || g.Detail != w.Detail {
+					t.Errorf("change %d = %s %s %q, want %s %s %q",
+						i, g.Kind, g.Disk.Serial, g.Detail, w.Kind, w.Disk.Serial, w.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestInventoryKey(t *testing.T) {
+	tests := []struct {
+		d    inventoryDisk
+		want string
+	}{
+		{inventoryDisk{Device: "/dev/sda", Slot: "enc0/slot1", Serial: "ZC1", WWN: "naa.5000c500a1b2c3d4"}, "serial:ZC1"},
+		{inventoryDisk{Device: "/dev/sda", Slot: "enc0/slot1", WWN: "naa.5000c500a1b2c3d4"}, "wwn:naa.5000c500a1b2c3d4"},
+		{inventoryDisk{Device: "/dev/sda", Slot: "enc0/slot1"}, "slot:enc0/slot1"},
+		{inventoryDisk{Device: "/dev/sda"}, "device:/dev/sda"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.key(); got != tt.want {
+			t.Errorf("key() = %q, want %q", got, tt.want)
+		}
+	}
+}
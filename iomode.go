@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// I/O modes for --io-mode.
+const (
+	ioDirect   = "direct"   // bypass the page cache: O_DIRECT, F_NOCACHE or FILE_FLAG_NO_BUFFERING
+	ioBuffered = "buffered" // through the page cache
+	ioDSync    = "dsync"    // through the page cache, every write durable before it returns
+	ioCompare  = "compare"  // speed and IOPS tests in direct and buffered mode, side by side
+)
+
+// benchIOMode is the mode every benchmark opens its files in. --io-mode
+// compare switches it between runs.
+var benchIOMode = ioDirect
+
+// openBenchFile opens a benchmark file in benchIOMode and notes the mode
+// actually achieved in mode. Direct I/O falls back to buffered where the
+// platform or filesystem refuses it (FUSE, some network filesystems), and
+// mode keeps the reason. A truncating open creates the file write-only.
+func openBenchFile(path string, flag int, mode *achievedMode) (*os.File, error) {
+	switch benchIOMode {
+	case ioBuffered, ioDSync:
+		if benchIOMode == ioDSync {
+			flag |= oDSync
+		}
+		f, err := os.OpenFile(path, flag, 0644)
+		if err == nil {
+			mode.note(benchIOMode, "")
+		}
+		return f, err
+	}
+
+	var f *os.File
+	var direct bool
+	var derr error
+	switch {
+	case flag&(os.O_WRONLY|os.O_RDWR) == 0:
+		f, direct, derr = openDirectRead(path)
+	case flag&os.O_TRUNC != 0:
+		f, direct, derr = openDirectWrite(path)
+	default:
+		f, direct, derr = openDirectRW(path)
+	}
+	if f == nil {
+		var err error
+		if f, err = os.OpenFile(path, flag, 0644); err != nil {
+			return nil, err
+		}
+		direct = setNoCache(f)
+	}
+	if direct {
+		mode.note(ioDirect, "")
+	} else {
+		mode.note(ioBuffered, directIOReason(derr))
+	}
+	return f, nil
+}
+
+// directIOReason explains a failed direct open.
+func directIOReason(err error) string {
+	switch {
+	case err == nil:
+		return "not supported on this platform"
+	case errors.Is(err, syscall.EINVAL):
+		return "the filesystem does not support it"
+	}
+	return err.Error()
+}
+
+// achievedMode collects the modes of a test's file handles. One buffered
+// handle makes the whole test buffered.
+type achievedMode struct {
+	mu   sync.Mutex
+	mode string
+	why  string // why direct I/O was not used
+}
+
+func (m *achievedMode) note(mode, why string) {
+	m.mu.Lock()
+	if m.mode == "" || (mode == ioBuffered && m.mode != ioBuffered) {
+		m.mode, m.why = mode, why
+	}
+	m.mu.Unlock()
+}
+
+func (m *achievedMode) get() (mode, why string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mode, m.why
+}
+
+// ioModeNote describes the mode a test ran in, and why it is less than was
+// asked for.
+func ioModeNote(mode, why string) string {
+	switch mode {
+	case ioDirect:
+		return "using direct I/O (bypassing OS cache)"
+	case ioDSync:
+		return "using buffered I/O with O_DSYNC writes (each write reaches the disk before returning)"
+	case ioBuffered:
+		if why != "" {
+			return "using buffered I/O (direct I/O not available: " + why + ")"
+		}
+		return "using buffered I/O (through the OS cache)"
+	}
+	return "I/O mode unknown"
+}
+
+// dioAlign is what direct I/O on a file must be aligned to. Offset 0 means
+// the file does not support direct I/O at all.
+type dioAlign struct {
+	Mem      int    // buffer address
+	Offset   int    // file offset and transfer size
+	Physical int    // physical sector size, where known
+	Source   string // statx, BLKSSZGET, sysfs, GetDiskFreeSpace, default
+}
+
+// benchAlignment is the alignment benchmarks keep on path: the direct I/O
+// alignment in direct mode, none otherwise.
+func benchAlignment(path string) dioAlign {
+	if benchIOMode != ioDirect {
+		return dioAlign{Offset: 1, Source: benchIOMode}
+	}
+	return dioAlignment(path)
+}
+
+// roundUp rounds a transfer size or offset granularity up to the alignment.
+func (a dioAlign) roundUp(n int) int {
+	if a.Offset <= 1 {
+		return n
+	}
+	return (n + a.Offset - 1) / a.Offset * a.Offset
+}
+
+// describe summarises the alignment, e.g. "4 KB (statx)".
+func (a dioAlign) describe() string {
+	if a.Offset == 0 {
+		return "direct I/O not supported (" + a.Source + ")"
+	}
+	if a.Offset == 1 {
+		return "none required (" + a.Source + ")"
+	}
+	s := formatSize(int64(a.Offset))
+	if a.Mem > 0 && a.Mem != a.Offset {
+		s += ", buffers " + formatSize(int64(a.Mem))
+	}
+	if a.Physical > a.Offset {
+		s += ", " + formatSize(int64(a.Physical)) + " physical sectors"
+	}
+	return s + " (" + a.Source + ")"
+}
+
+// runIOCompare runs the speed and IOPS tests first with direct and then
+// with buffered I/O, prints both reports and a table of how much the page
+// cache changes each result.
+func runIOCompare(disk DiskInfo, testDir string, opts benchOptions, mon benchObserver) {
+	var runs [2]benchRun
+	for i, mode := range []string{ioDirect, ioBuffered} {
+		benchIOMode = mode
+		fmt.Printf("  %sI/O mode: %s%s\n", colorBold, mode, colorReset)
+		if opts.Speed {
+			runs[i].Speed = speedTest(testDir, speedTestSize(disk, testDir, opts.Size), defaultBlockSize, opts.Streams, opts.SeqQD, mon)
+			fmt.Println()
+			printSpeedReport(runs[i].Speed, disk)
+			fmt.Println()
+		}
+		if opts.IOPS {
+			runs[i].IOPS = iopsTest(testDir, opts.Duration, opts.Sync, disk, mon)
+			fmt.Println()
+			if len(runs[i].IOPS) > 0 {
+				printIOPSReport(runs[i].IOPS, disk.DiskType)
+			}
+			fmt.Println()
+		}
+	}
+	benchIOMode = ioDirect
+	printIOCompareReport(runs[0], runs[1], opts)
+}
+
+// printIOCompareReport prints direct and buffered results side by side.
+func printIOCompareReport(direct, buffered benchRun, opts benchOptions) {
+	fmt.Printf("  %sPage cache effect%s\n", colorBold, colorReset)
+	headers := []string{"Test", "Direct", "Buffered", "Cache Effect"}
+	aligns := []byte{'l', 'r', 'r', 'r'}
+	var rows [][]string
+	add := func(name string, d, b float64, decimals int, unit string) {
+		effect := "-"
+		if d > 0 {
+			effect = fmt.Sprintf("%+.0f%%", (b/d-1)*100)
+			if b >= d*2 {
+				effect = fmt.Sprintf("%s%.1fx%s", colorYellow, b/d, colorReset)
+			}
+		}
+		rows = append(rows, []string{name + " (" + unit + ")", formatFloat(d, decimals), formatFloat(b, decimals), effect})
+	}
+	if opts.Speed {
+		add("Sequential Read", direct.Speed.ReadMBPS, buffered.Speed.ReadMBPS, 1, "MB/s")
+		add("Sequential Write", direct.Speed.WriteMBPS, buffered.Speed.WriteMBPS, 1, "MB/s")
+	}
+	if opts.IOPS && len(direct.IOPS) == len(buffered.IOPS) {
+		for i, d := range direct.IOPS {
+			b := buffered.IOPS[i]
+			add("Random Read "+d.Label, d.ReadIOPS, b.ReadIOPS, 0, "IOPS")
+			add("Random Write "+d.Label, d.WriteIOPS, b.WriteIOPS, 0, "IOPS")
+		}
+	}
+	printTable(headers, rows, aligns)
+	fmt.Println()
+	if direct.Speed.IOMode == ioBuffered || (len(direct.IOPS) > 0 && direct.IOPS[0].IOMode == ioBuffered) {
+		fmt.Printf("  %sWarning: direct I/O was not available, both columns went through the page cache%s\n", colorYellow, colorReset)
+	}
+	fmt.Printf("  %sBuffered results well above direct ones measure RAM, not the disk; the workload's own%s\n", colorDim, colorReset)
+	fmt.Printf("  %sI/O mode decides which column applies.%s\n", colorDim, colorReset)
+	fmt.Println()
+}
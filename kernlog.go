@@ -0,0 +1,78 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// kernelRecentLines is how many matching kernel messages the report keeps.
+const kernelRecentLines = 5
+
+// kernelErrorPatterns classify kernel messages that indicate a disk problem.
+// The first matching pattern decides the category.
+var kernelErrorPatterns = []struct {
+	category string
+	re       *regexp.Regexp
+}{
+	{"Medium error", regexp.MustCompile(`(?i)medium error|unrecovered read error|critical medium error`)},
+	{"I/O error", regexp.MustCompile(`(?i)blk_update_request: .*error|I/O error|buffer i/o error|FAILED Result:`)},
+	{"Timeout", regexp.MustCompile(`(?i)\btimeout\b|timing out command|timed out`)},
+	{"Link reset", regexp.MustCompile(`(?i)hard resetting link|SATA link down|COMRESET failed|link is slow|resetting controller|controller is down|exception Emask`)},
+	{"Offline", regexp.MustCompile(`(?i)rejecting I/O to offline device|device offlined|removing from array`)},
+	{"Filesystem error", regexp.MustCompile(`(?i)(EXT4-fs|XFS|BTRFS|F2FS).*(error|corrupt)`)},
+}
+
+// scanKernelLog collects kernel messages about the device, its partitions
+// and the link/controller it sits on. Returns nil if the log is unreadable.
+func scanKernelLog(device string) *KernelLog {
+	lines, source, err := kernelLogPlatform()
+	if err != nil {
+		return nil
+	}
+	return matchKernelLog(lines, source, kernelDeviceNames(device))
+}
+
+// matchKernelLog filters log lines mentioning any of names (whole words;
+// partition and link suffixes are allowed) and classifies the error messages.
+func matchKernelLog(lines []string, source string, names []string) *KernelLog {
+	result := &KernelLog{Source: source, Counts: make(map[string]int)}
+	if len(names) == 0 {
+		return result
+	}
+	mention := kernelMention(names)
+
+	for _, line := range lines {
+		if !mention.MatchString(line) {
+			continue
+		}
+		for _, p := range kernelErrorPatterns {
+			if p.re.MatchString(line) {
+				result.Counts[p.category]++
+				result.Total++
+				result.Recent = append(result.Recent, line)
+				if len(result.Recent) > kernelRecentLines {
+					result.Recent = result.Recent[1:]
+				}
+				break
+			}
+		}
+	}
+	return result
+}
+
+// kernelMention matches a mention of any of names. A name ending in a letter
+// takes partition numbers (sda -> sda1); one ending in a digit takes a p
+// partition (nvme0n1 -> nvme0n1p1) or a link suffix (ata3 -> ata3.00), but
+// never another digit, so ata1 does not match ata10.
+func kernelMention(names []string) *regexp.Regexp {
+	alts := make([]string, len(names))
+	for i, n := range names {
+		alts[i] = regexp.QuoteMeta(n)
+		if last := n[len(n)-1]; last >= '0' && last <= '9' {
+			alts[i] += `(?:p\d+|\.\d+)?`
+		} else {
+			alts[i] += `\d*`
+		}
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(alts, "|") + `)\b`)
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// kernelLogPlatform reads the kernel ring buffer from /dev/kmsg, falling
+// back to `dmesg` when kmsg is not readable.
+func kernelLogPlatform() ([]string, string, error) {
+	if lines, err := readKmsg(); err == nil {
+		return lines, "/dev/kmsg", nil
+	}
+	out, err := runCmd("dmesg")
+	if err != nil {
+		return nil, "", err
+	}
+	return strings.Split(strings.TrimSpace(out), "\n"), "dmesg", nil
+}
+
+// readKmsg reads all records currently in /dev/kmsg without blocking.
+// Records look like "4,1234,5678901,-;message"; continuation lines with
+// key=value metadata are skipped.
+func readKmsg() ([]string, error) {
+	fd, err := syscall.Open("/dev/kmsg", syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	var lines []string
+	buf := make([]byte, 8192)
+	for {
+		n, err := syscall.Read(fd, buf)
+		if err == syscall.EPIPE {
+			continue // record overwritten while reading; skip it
+		}
+		if err == syscall.EAGAIN || n <= 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := string(buf[:n])
+		if i := strings.IndexByte(rec, '\n'); i >= 0 {
+			rec = rec[:i]
+		}
+		header, msg, ok := strings.Cut(rec, ";")
+		if !ok {
+			continue
+		}
+		fields := strings.Split(header, ",")
+		ts := 0.0
+		if len(fields) >= 3 {
+			if us, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+				ts = float64(us) / 1e6
+			}
+		}
+		lines = append(lines, fmt.Sprintf("[%12.6f] %s", ts, msg))
+	}
+	return lines, nil
+}
+
+// kernelDeviceNames returns the names the kernel uses for a disk in log
+// messages: the block device, plus its ATA port (ata3) or NVMe controller.
+func kernelDeviceNames(device string) []string {
+	disk := sysfsDiskName(device)
+	names := []string{disk}
+	real, err := filepath.EvalSymlinks(filepath.Join("/sys/block", disk, "device"))
+	if err != nil {
+		return names
+	}
+	if strings.HasPrefix(disk, "nvme") {
+		return append(names, filepath.Base(real)) // nvme0
+	}
+	for _, part := range strings.Split(real, "/") {
+		if strings.HasPrefix(part, "ata") {
+			if _, err := strconv.Atoi(part[3:]); err == nil {
+				names = append(names, part)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+func kernelLogPlatform() ([]string, string, error) {
+	return nil, "", errors.New("kernel log scanning is only supported on Linux")
+}
+
+func kernelDeviceNames(device string) []string {
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestMatchKernelLogBoundaries(t *testing.T) {
+	lines := []string{
+		"[    1.000000] ata1.00: exception Emask 0x0 SAct 0x0 SErr 0x0 action 0x6 frozen",
+		"[    2.000000] ata10.00: exception Emask 0x0 SAct 0x0 SErr 0x0 action 0x6 frozen",
+		"[    3.000000] blk_update_request: I/O error, dev sda, sector 2048 op 0x0:(READ)",
+		"[    4.000000] blk_update_request: I/O error, dev sdaa, sector 2048 op 0x0:(READ)",
+		"[    5.000000] Buffer I/O error on dev sda1, logical block 0, async page read",
+		"[    6.000000] nvme nvme1: I/O 12 QID 3 timeout, aborting",
+		"[    7.000000] nvme nvme10: I/O 12 QID 3 timeout, aborting",
+		"[    8.000000] EXT4-fs error (device nvme1n1p2): ext4_find_entry:1455: comm ls: reading directory lblock 0",
+		"[    9.000000] EXT4-fs error (device nvme1n10p2): ext4_find_entry:1455: comm ls: reading directory lblock 0",
+	}
+	got := matchKernelLog(lines, "dmesg", []string{"sda", "ata1", "nvme1n1", "nvme1"})
+	want := map[string]int{"Link reset": 1, "I/O error": 2, "Timeout": 1, "Filesystem error": 1}
+	if got.Total != 5 {
+		t.Errorf("Total = %d, want 5 (recent: %q)", got.Total, got.Recent)
+	}
+	for cat, n := range want {
+		if got.Counts[cat] != n {
+			t.Errorf("Counts[%q] = %d, want %d", cat, got.Counts[cat], n)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LinkInfo is the bus link a disk negotiated, and the fastest link it
+// supports, with the theoretical bandwidth of each.
+type LinkInfo struct {
+	Bus      string // PCIe, SATA, USB
+	Speed    string // negotiated, e.g. "8.0 GT/s", "3.0 Gbps", "480 Mbps"
+	MaxSpeed string
+	Width    int // PCIe lanes, 0 otherwise
+	MaxWidth int
+	MBPS     float64 // theoretical bandwidth of the negotiated link
+	MaxMBPS  float64 // theoretical bandwidth of the maximum link
+}
+
+// leadingFloat parses the number at the start of a sysfs rate such as
+// "8.0 GT/s PCIe" or "6.0 Gbps".
+func leadingFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.Fields(s + " ")[0], 64)
+	return f
+}
+
+// pcieLaneMBPS returns the usable MB/s of one PCIe lane at a transfer rate:
+// 8b/10b encoding up to Gen2, 128b/130b from Gen3.
+func pcieLaneMBPS(gts float64) float64 {
+	if gts <= 5 {
+		return gts * 1000 / 10
+	}
+	return gts * 1000 / 8 * 128 / 130
+}
+
+// sataMBPS returns the usable MB/s of a SATA link (8b/10b).
+func sataMBPS(gbps float64) float64 {
+	return gbps * 1000 / 10
+}
+
+// usbMBPS returns the signalling bandwidth of a USB link in MB/s: raw up
+// to USB 2, 8b/10b for 5 Gbps and 128b/132b above.
+func usbMBPS(mbps float64) float64 {
+	switch {
+	case mbps <= 480:
+		return mbps / 8
+	case mbps <= 5000:
+		return mbps / 10
+	default:
+		return mbps / 8 * 128 / 132
+	}
+}
+
+// usbVersionMbps returns the speed a device's bcdUSB version guarantees it
+// can negotiate. The version only names the spec the device follows, and
+// 3.1 and 3.2 are common on 5 Gbps Gen 1 devices, so every 3.x device counts
+// as 5 Gbps. USB 2 versions guarantee nothing above full speed, and give no
+// maximum; the negotiated speed is the rate.
+func usbVersionMbps(version string) float64 {
+	if leadingFloat(strings.TrimSpace(version)) >= 3.0 {
+		return 5000
+	}
+	return 0
+}
+
+// degraded reports whether the link came up slower or narrower than the
+// device supports.
+func (l *LinkInfo) degraded() bool {
+	return l.MaxMBPS > 0 && l.MBPS > 0 && l.MBPS < l.MaxMBPS*0.99
+}
+
+// describe formats the link for reports, e.g. "PCIe 8.0 GT/s x2 (max 8.0 GT/s x4)".
+func (l *LinkInfo) describe() string {
+	s := l.Bus + " " + l.Speed
+	if l.Width > 0 {
+		s += fmt.Sprintf(" x%d", l.Width)
+	}
+	if l.degraded() {
+		best := l.MaxSpeed
+		if l.MaxWidth > 0 {
+			best += fmt.Sprintf(" x%d", l.MaxWidth)
+		}
+		s += " (max " + best + ")"
+	}
+	return s
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func linkInfoPlatform(device string) *LinkInfo {
+	return nil // link speeds are read from Linux sysfs
+}
@@ -19,6 +19,8 @@ func main() {
 
 	// CLI flags
 	listFlag := flag.Bool("list", false, "List detected disks and exit")
+	treeFlag := flag.Bool("tree", false, "With --list: show partitions, filesystems, mounts and dm/md holders as a tree")
+	verboseFlag := flag.Bool("verbose", false, "With --list: also show block sizes, queue, cache and TRIM settings")
 	healthFlag := flag.Bool("health", false, "Run health check only")
 	speedFlag := flag.Bool("speed", false, "Run speed test only")
 	iopsFlag := flag.Bool("iops", false, "Run IOPS test only")
@@ -26,22 +28,61 @@ func main() {
 	sizeFlag := flag.String("size", "", "Test file size (e.g., 256M, 1G, 4G). Default: auto")
 	durationFlag := flag.Int("duration", 10, "IOPS test duration in seconds")
 	syncFlag := flag.Bool("sync", false, "Fsync after each IOPS write (measures real disk, not cache)")
+	streamsFlag := flag.Int("streams", 1, "Speed test: parallel sequential streams, each on its own region of the file")
+	seqQDFlag := flag.Int("seq-qd", 1, "Speed test: requests in flight per sequential stream")
+	ioModeFlag := flag.String("io-mode", "direct", "Benchmark I/O: direct, buffered, dsync, or compare (speed/IOPS in direct and buffered)")
+	policyFlag := flag.String("health-policy", "", "JSON file with health thresholds (overrides built-in rules)")
+	historyFlag := flag.String("history-dir", "", "Record health results in this directory (default with --trend: user cache dir)")
+	trendFlag := flag.Bool("trend", false, "Record health to history and show trends and alerts")
+	offlineFlag := flag.Bool("offline", false, "Analyse saved smartctl output files (JSON or text) given as arguments")
+	alertsOnlyFlag := flag.Bool("alerts-only", false, "With --trend: print only trend alerts (for cron)")
+	invSaveFlag := flag.String("inventory-save", "", "Save a snapshot of disk identities (serial, WWN, slot, firmware) to this file")
+	invDiffFlag := flag.String("inventory-diff", "", "Compare disks with a saved snapshot; exit 1 if anything changed")
+	parallelFlag := flag.Bool("parallel", false, "Benchmark all selected disks at the same time and compare with solo runs")
+	burnInFlag := flag.String("burn-in", "", "Soak test: mixed write/verify/random load on all selected disks for this long (e.g., 24h, 2d)")
+	burnInIntervalFlag := flag.String("burn-in-interval", "15m", "With --burn-in: time between health snapshots")
+	burnInDirFlag := flag.String("burn-in-dir", ".", "With --burn-in: directory for the per-disk acceptance reports")
+	profileFlag := flag.String("profile", "", "Run workload profiles, e.g. oltp,vm,media,backup,build ('list' shows all)")
+	profileFileFlag := flag.String("profile-file", "", "JSON file with extra workload profiles (same names replace built-ins)")
+	presetFlag := flag.String("preset", "", "Run a benchmark preset: cdm (CrystalDiskMark default tests and layout)")
+	sweepFlag := flag.Bool("sweep", false, "Sequential throughput sweep over transfer sizes from 512 B to 64 MB, with a chart")
+	noTempFlag := flag.Bool("no-temp", false, "Don't monitor drive temperature during benchmarks")
+	typeFlag := flag.String("type", "", "Only test detected disks of these types, e.g. nvme,ssd (or interface, e.g. raid)")
+	excludeFlag := flag.String("exclude", "", "Skip these detected disks (device, by-id link, serial or mount point), comma-separated")
+	mountedOnlyFlag := flag.Bool("mounted-only", false, "Only test detected disks that are mounted")
+	minSizeFlag := flag.String("min-size", "", "Only test detected disks at least this large (e.g., 100G)")
 	noColorFlag := flag.Bool("no-color", false, "Disable colored output")
 	versionFlag := flag.Bool("version", false, "Show version and exit")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: diskbench [options] [target]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: diskbench [options] [target ...]\n\n")
 		fmt.Fprintf(os.Stderr, "DiskBench - Cross-platform disk health, speed & IOPS tester\n\n")
 		fmt.Fprintf(os.Stderr, "Arguments:\n")
-		fmt.Fprintf(os.Stderr, "  target    Path or device to test (e.g., /dev/sda, /tmp, D:\\, /mnt/nfs)\n\n")
+		fmt.Fprintf(os.Stderr, "  target    Paths or devices to test (e.g., /dev/sda, /tmp, D:\\, /mnt/nfs)\n")
+		fmt.Fprintf(os.Stderr, "            Without targets, all detected disks passing the filters are tested\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  diskbench --list              List detected disks\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --list --verbose    Also show queue/cache/TRIM properties\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --list --tree       Disks, partitions, mounts and holders\n")
 		fmt.Fprintf(os.Stderr, "  diskbench /tmp --speed        Speed test on /tmp\n")
 		fmt.Fprintf(os.Stderr, "  diskbench /dev/sda --health   Health check on /dev/sda\n")
 		fmt.Fprintf(os.Stderr, "  diskbench --all --size 1G     All tests, 1GB test file\n")
 		fmt.Fprintf(os.Stderr, "  diskbench /tmp --iops --sync  IOPS with fsync (real disk perf)\n")
+		fmt.Fprintf(os.Stderr, "  diskbench /nvme --speed --streams 4 --seq-qd 8  Full NVMe/RAID bandwidth\n")
+		fmt.Fprintf(os.Stderr, "  diskbench /data --io-mode compare  How much the page cache helps\n")
+		fmt.Fprintf(os.Stderr, "  diskbench /data1 /data2 --speed  Test several targets\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --type nvme,ssd --exclude /dev/sda --min-size 100G\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --type hdd --speed --parallel  Find HBA/backplane bottlenecks\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --type hdd --burn-in 24h  Acceptance soak test of new disks\n")
+		fmt.Fprintf(os.Stderr, "  diskbench /var/lib/mysql --profile oltp  Is this disk fit for a database?\n")
+		fmt.Fprintf(os.Stderr, "  diskbench D:\\ --preset cdm    CrystalDiskMark-style results\n")
+		fmt.Fprintf(os.Stderr, "  diskbench /data --sweep        Throughput by transfer size (ATTO-style)\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --health --health-policy policy.json\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --trend --alerts-only  Cron: report only health trend alerts\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --offline dumps/*.json  Health report from saved smartctl output\n")
+		fmt.Fprintf(os.Stderr, "  diskbench --inventory-diff before.json  Disks added/removed/replaced since snapshot\n")
 	}
 
 	flag.Parse()
@@ -51,6 +92,24 @@ func main() {
 		return
 	}
 
+	if *policyFlag != "" {
+		p, err := loadHealthPolicy(*policyFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: health policy: %v\n", err)
+			os.Exit(1)
+		}
+		activeHealthPolicy = p
+	}
+
+	// Health history
+	if *alertsOnlyFlag {
+		*trendFlag = true
+	}
+	historyDir := *historyFlag
+	if *trendFlag && historyDir == "" {
+		historyDir = defaultHistoryDir()
+	}
+
 	// Signal handling: clean up test files on interrupt
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -64,44 +123,205 @@ func main() {
 	// Initialize colors
 	initColors(*noColorFlag)
 
+	// Targets and filters
+	targets := flag.Args()
+	filter, err := parseDiskFilter(*typeFlag, *excludeFlag, *mountedOnlyFlag, *minSizeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Cron mode: record health quietly and print only trend alerts
+	if *alertsOnlyFlag {
+		for _, disk := range selectDisks(targets, filter) {
+			for _, t := range trackHealth(historyDir, disk, checkHealth(disk)) {
+				printTrendAlerts(t.Alerts)
+			}
+		}
+		return
+	}
+
 	// Print banner
 	printHeader()
 	printSystemInfo()
 	fmt.Println()
 
-	// Target
-	target := ""
-	if flag.NArg() > 0 {
-		target = flag.Arg(0)
+	// Offline mode: analyse saved smartctl output instead of live disks
+	if *offlineFlag {
+		if flag.NArg() == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --offline needs one or more smartctl output files\n")
+			os.Exit(1)
+		}
+		analyzeSmartFiles(flag.Args())
+		return
+	}
+
+	// Inventory mode: snapshot disk identities, or compare with a snapshot
+	if *invSaveFlag != "" || *invDiffFlag != "" {
+		inv := takeInventory(filter.apply(detectDisks()))
+		changed := false
+		if *invDiffFlag != "" {
+			old, err := loadInventory(*invDiffFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: inventory: %v\n", err)
+				os.Exit(1)
+			}
+			changes := diffInventory(old, inv)
+			printInventoryDiff(old, changes)
+			changed = len(changes) > 0
+		}
+		if *invSaveFlag != "" {
+			if err := saveInventory(*invSaveFlag, inv); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: inventory: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("  Saved inventory of %d disk(s) to %s\n", len(inv.Disks), *invSaveFlag)
+		}
+		if changed {
+			os.Exit(1)
+		}
+		return
 	}
 
 	// List mode
 	if *listFlag {
-		disks := detectDisks()
+		disks := filter.apply(detectDisks())
 		if len(disks) == 0 {
 			fmt.Println("  No disks detected.")
+		} else if *treeFlag {
+			printDiskTree(disks)
 		} else {
 			printDiskList(disks)
+			if *verboseFlag {
+				fmt.Println()
+				printDiskDetails(disks)
+			}
+		}
+		return
+	}
+
+	// Workload profiles
+	var profiles []profile
+	if *profileFlag != "" || *profileFileFlag != "" {
+		var user []profile
+		if *profileFileFlag != "" {
+			if user, err = loadProfiles(*profileFileFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: profiles: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		available := availableProfiles(user)
+		if *profileFlag == "" || *profileFlag == "list" {
+			printProfileList(available)
+			return
+		}
+		if profiles, err = selectProfiles(*profileFlag, available); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch *presetFlag {
+	case "", "cdm":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown preset %q (available: cdm)\n", *presetFlag)
+		os.Exit(1)
+	}
+	if *streamsFlag < 1 || *streamsFlag > 64 {
+		fmt.Fprintf(os.Stderr, "Error: --streams must be 1-64\n")
+		os.Exit(1)
+	}
+	if *seqQDFlag < 1 || *seqQDFlag > 256 {
+		fmt.Fprintf(os.Stderr, "Error: --seq-qd must be 1-256\n")
+		os.Exit(1)
+	}
+	compareIO := false
+	switch *ioModeFlag {
+	case ioDirect, ioBuffered, ioDSync:
+		benchIOMode = *ioModeFlag
+	case ioCompare:
+		compareIO = true
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown --io-mode %q (direct, buffered, dsync or compare)\n", *ioModeFlag)
+		os.Exit(1)
+	}
+	if compareIO {
+		// Compare covers the solo speed and IOPS tests only.
+		var with []string
+		if *parallelFlag {
+			with = append(with, "--parallel")
+		}
+		if *profileFlag != "" || *profileFileFlag != "" {
+			with = append(with, "--profile")
+		}
+		if *sweepFlag {
+			with = append(with, "--sweep")
+		}
+		if *presetFlag != "" {
+			with = append(with, "--preset "+*presetFlag)
+		}
+		if len(with) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --io-mode compare does not apply to %s\n", strings.Join(with, ", "))
+			os.Exit(1)
+		}
+	}
+
+	// Burn-in mode: hours of load with health snapshots, pass/fail per disk
+	if *burnInFlag != "" {
+		opts := burnInOptions{ReportDir: *burnInDirFlag}
+		var err error
+		if opts.Duration, err = parseDuration(*burnInFlag); err != nil || opts.Duration <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --burn-in: invalid duration %q\n", *burnInFlag)
+			os.Exit(1)
+		}
+		if opts.Interval, err = parseDuration(*burnInIntervalFlag); err != nil || opts.Interval <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --burn-in-interval: invalid duration %q\n", *burnInIntervalFlag)
+			os.Exit(1)
+		}
+		if *sizeFlag != "" {
+			opts.Size = parseSize(*sizeFlag)
+		}
+		if compareIO {
+			fmt.Fprintf(os.Stderr, "Error: --io-mode compare does not apply to --burn-in\n")
+			os.Exit(1)
+		}
+		if benchIOMode != ioDirect {
+			fmt.Printf("  %sWarning: --io-mode %s: verify reads may be served from the page cache instead of the disk%s\n\n",
+				colorYellow, benchIOMode, colorReset)
+		}
+		disks := selectDisks(targets, filter)
+		if len(targets) == 0 {
+			printDiskList(disks)
+			fmt.Println()
+		}
+		if !runBurnIn(disks, opts) {
+			os.Exit(1)
 		}
 		return
 	}
 
 	// Determine which tests to run
-	runHealth := *healthFlag
+	runHealth := *healthFlag || *trendFlag
 	runSpeed := *speedFlag
 	runIOPS := *iopsFlag
-	if *allFlag || (!runHealth && !runSpeed && !runIOPS) {
+	if *allFlag || (!runHealth && !runSpeed && !runIOPS && len(profiles) == 0 && *presetFlag == "" && !*sweepFlag) {
 		runHealth = true
 		runSpeed = true
 		runIOPS = true
 	}
 
 	// Resolve disks
-	var disks []DiskInfo
-	if target != "" {
-		disks = resolveTarget(target)
-	} else {
-		disks = detectDisks()
+	opts := benchOptions{
+		Speed: runSpeed, IOPS: runIOPS, Duration: *durationFlag, Sync: *syncFlag,
+		Streams: *streamsFlag, SeqQD: *seqQDFlag,
+	}
+	if *sizeFlag != "" {
+		opts.Size = parseSize(*sizeFlag)
+	}
+
+	disks := selectDisks(targets, filter)
+	parallel := *parallelFlag && (runSpeed || runIOPS)
+	if len(targets) == 0 {
 		if len(disks) == 0 {
 			fmt.Println("  No disks detected.")
 			return
@@ -120,60 +340,122 @@ func main() {
 			result := checkHealth(disk)
 			printHealthReport(result)
 			fmt.Println()
-		}
 
-		// Determine test directory
-		testDir := disk.MountPoint
-		if testDir == "" || !isDir(testDir) {
-			if runSpeed || runIOPS {
-				fmt.Fprintf(os.Stdout, "  %sWarning: No writable mount point for %s, skipping benchmarks.%s\n",
-					colorYellow, disk.Device, colorReset)
+			if historyDir != "" {
+				trends := trackHealth(historyDir, disk, result)
+				if *trendFlag {
+					printDiskTrends(trends)
+				}
 			}
+		}
+
+		// With --parallel the speed and IOPS tests run together after the health checks
+		soloSpeed := runSpeed && !parallel
+		soloIOPS := runIOPS && !parallel
+		if !soloSpeed && !soloIOPS && len(profiles) == 0 && *presetFlag == "" && !*sweepFlag {
 			continue
 		}
 
-		// Check write permission
-		if !isWritable(testDir) {
-			if runSpeed || runIOPS {
-				fmt.Fprintf(os.Stdout, "  %sWarning: %s is not writable, skipping benchmarks.%s\n",
-					colorYellow, testDir, colorReset)
-			}
+		// Determine test directory
+		testDir := benchDir(disk, true)
+		if testDir == "" {
 			continue
 		}
 
-		// Speed test
-		if runSpeed {
-			testSize := int64(0)
-			if *sizeFlag != "" {
-				testSize = parseSize(*sizeFlag)
-			}
-			if testSize <= 0 {
-				testSize = autoTestSize(disk)
-			}
+		// Sample drive temperature while the benchmarks run
+		var mon *thermalMonitor
+		if !*noTempFlag {
+			mon = startThermalMonitor(disk)
+		}
+
+		// Count what the device itself completes during the speed and IOPS tests
+		devStats := startDiskStats(testDir, mon)
 
-			// Check available space
-			testSize = checkAvailableSpace(testDir, testSize)
+		// Direct against buffered speed and IOPS
+		if compareIO && (soloSpeed || soloIOPS) {
+			cmp := opts
+			cmp.Speed, cmp.IOPS = soloSpeed, soloIOPS
+			runIOCompare(disk, testDir, cmp, devStats)
+			soloSpeed, soloIOPS = false, false
+		}
 
-			result := speedTest(testDir, testSize, defaultBlockSize)
+		// Speed test
+		if soloSpeed {
+			result := speedTest(testDir, speedTestSize(disk, testDir, opts.Size), defaultBlockSize, opts.Streams, opts.SeqQD, devStats)
 			fmt.Println()
-			printSpeedReport(result, disk.DiskType)
+			printSpeedReport(result, disk)
 			fmt.Println()
 		}
 
 		// IOPS test
-		if runIOPS {
-			results := iopsTest(testDir, *durationFlag, *syncFlag, disk)
+		if soloIOPS {
+			results := iopsTest(testDir, opts.Duration, opts.Sync, disk, devStats)
 			fmt.Println()
 			if len(results) > 0 {
 				printIOPSReport(results, disk.DiskType)
 			}
 			fmt.Println()
 		}
+
+		printDeviceIOReport(devStats.finish())
+
+		// Transfer size sweep
+		if *sweepFlag {
+			runSweep(disk, testDir, opts.Size, mon)
+		}
+
+		// CrystalDiskMark preset
+		if *presetFlag == "cdm" {
+			runCDM(disk, testDir, opts.Size, mon)
+		}
+
+		// Workload profiles
+		if len(profiles) > 0 {
+			runProfiles(disk, testDir, profiles, opts, mon)
+		}
+
+		printThermalReport(mon.finish())
+	}
+
+	if parallel {
+		runParallel(disks, opts)
 	}
 
 	fmt.Println("  Done.")
 }
 
+// benchDir returns the directory to write benchmark files in for a disk,
+// or "" (with a warning when benchmarks were requested) if there is none.
+func benchDir(disk DiskInfo, warn bool) string {
+	testDir := disk.MountPoint
+	if testDir == "" || !isDir(testDir) {
+		if warn {
+			fmt.Fprintf(os.Stdout, "  %sWarning: No writable mount point for %s, skipping benchmarks.%s\n",
+				colorYellow, disk.Device, colorReset)
+		}
+		return ""
+	}
+
+	// Check write permission
+	if !isWritable(testDir) {
+		if warn {
+			fmt.Fprintf(os.Stdout, "  %sWarning: %s is not writable, skipping benchmarks.%s\n",
+				colorYellow, testDir, colorReset)
+		}
+		return ""
+	}
+	return testDir
+}
+
+// speedTestSize picks the sequential test size: the requested size or an
+// automatic one, capped by the free space in testDir.
+func speedTestSize(disk DiskInfo, testDir string, size int64) int64 {
+	if size <= 0 {
+		size = autoTestSize(disk)
+	}
+	return checkAvailableSpace(testDir, size)
+}
+
 // reorderArgs moves flags before positional args so flag.Parse() sees them.
 func reorderArgs() {
 	var flags, positional []string
@@ -196,7 +478,9 @@ func reorderArgs() {
 				// Could be a flag value; check known value-flags
 				base := strings.TrimLeft(a, "-")
 				switch base {
-				case "size", "duration":
+				case "size", "duration", "health-policy", "history-dir", "inventory-save", "inventory-diff",
+					"type", "exclude", "min-size", "burn-in", "burn-in-interval", "burn-in-dir",
+					"profile", "profile-file", "preset", "streams", "seq-qd", "io-mode":
 					skip = true
 				}
 			}
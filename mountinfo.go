@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mountEntry is one line of /proc/self/mountinfo.
+type mountEntry struct {
+	Major, Minor uint32
+	Root         string // path within the filesystem (bind mounts, subvolumes)
+	MountPoint   string
+	FSType       string
+	Source       string
+	Options      []string // per-mount options followed by superblock options
+}
+
+// parseMountinfo parses the contents of /proc/<pid>/mountinfo, see proc(5):
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseMountinfo(data string) []mountEntry {
+	var entries []mountEntry
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || sep+2 >= len(fields) {
+			continue
+		}
+		majMin := strings.SplitN(fields[2], ":", 2)
+		if len(majMin) != 2 {
+			continue
+		}
+		major, err1 := strconv.ParseUint(majMin[0], 10, 32)
+		minor, err2 := strconv.ParseUint(majMin[1], 10, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		e := mountEntry{
+			Major:      uint32(major),
+			Minor:      uint32(minor),
+			Root:       unescapeMountinfo(fields[3]),
+			MountPoint: unescapeMountinfo(fields[4]),
+			FSType:     fields[sep+1],
+			Source:     unescapeMountinfo(fields[sep+2]),
+			Options:    strings.Split(fields[5], ","),
+		}
+		if sep+3 < len(fields) {
+			e.Options = append(e.Options, strings.Split(fields[sep+3], ",")...)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// benchOptionKeys are the mount options that change benchmark results:
+// write caching, atime updates, barriers, compression, journaling and,
+// for network filesystems, transfer sizes and caching.
+var benchOptionKeys = map[string]bool{
+	"ro": true, "sync": true, "dirsync": true, "noatime": true, "strictatime": true,
+	"lazytime": true, "nobarrier": true, "barrier": true, "discard": true,
+	"compress": true, "compress-force": true, "nodatacow": true, "autodefrag": true,
+	"data": true, "commit": true, "journal_async_commit": true, "nodelalloc": true,
+	"dax": true, "vers": true, "rsize": true, "wsize": true, "soft": true,
+	"cache": true, "actimeo": true, "noac": true,
+}
+
+// benchmarkMountOptions filters mount options down to benchOptionKeys,
+// dropping duplicates between the per-mount and superblock lists.
+func benchmarkMountOptions(opts []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, o := range opts {
+		key, _, _ := strings.Cut(o, "=")
+		if benchOptionKeys[key] && !seen[o] {
+			seen[o] = true
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// mountForPath picks the mount a path lives on: the entry with the longest
+// mount point containing path, preferring entries for the path's device so
+// bind mounts and btrfs subvolumes resolve to the right source.
+func mountForPath(entries []mountEntry, path string, major, minor uint32) *mountEntry {
+	var best *mountEntry
+	bestDev := false
+	for i := range entries {
+		e := &entries[i]
+		mp := e.MountPoint
+		if mp != "/" && path != mp && !strings.HasPrefix(path, mp+"/") {
+			continue
+		}
+		sameDev := e.Major == major && e.Minor == minor
+		switch {
+		case best == nil,
+			sameDev && !bestDev,
+			sameDev == bestDev && len(mp) >= len(best.MountPoint):
+			best, bestDev = e, sameDev
+		}
+	}
+	return best
+}
+
+// unescapeMountinfo decodes the octal escapes (\040 for space, \011 tab,
+// \012 newline, \134 backslash) the kernel uses in mountinfo paths.
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// resolveMountPlatform finds the mount and source device for path using
+// stat(2) device numbers and /proc/self/mountinfo.
+func resolveMountPlatform(path string) (string, *mountEntry) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	var st syscall.Stat_t
+	if syscall.Stat(abs, &st) != nil {
+		return "", nil
+	}
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return "", nil
+	}
+	dev := uint64(st.Dev)
+	major := uint32((dev>>8)&0xfff | (dev>>32)&^0xfff)
+	minor := uint32(dev&0xff | (dev>>12)&^0xff)
+	m := mountForPath(parseMountinfo(string(data)), abs, major, minor)
+	if m == nil {
+		return "", nil
+	}
+
+	// Keep the mount source when it names a real node (/dev/mapper/vg-lv),
+	// otherwise (/dev/root, btrfs subvolumes) look it up by major:minor.
+	device := m.Source
+	if strings.HasPrefix(device, "/dev/") {
+		if _, err := os.Stat(device); err == nil {
+			return device, m
+		}
+	}
+	if link, err := os.Readlink(fmt.Sprintf("/sys/dev/block/%d:%d", m.Major, m.Minor)); err == nil {
+		device = "/dev/" + filepath.Base(link)
+	}
+	return device, m
+}
+
+// readMountinfo returns this process's mount table.
+func readMountinfo() []mountEntry {
+	data, _ := os.ReadFile("/proc/self/mountinfo")
+	return parseMountinfo(string(data))
+}
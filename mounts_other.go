@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func resolveMountPlatform(path string) (string, *mountEntry) {
+	return "", nil // no mountinfo; findDeviceForPath falls back to df
+}
@@ -0,0 +1,88 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// nvmeAdminCmd mirrors struct nvme_admin_cmd from <linux/nvme_ioctl.h>.
+type nvmeAdminCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+}
+
+const (
+	nvmeIoctlAdminCmd = 0xC0484E41 // _IOWR('N', 0x41, struct nvme_admin_cmd)
+
+	nvmeAdminGetLogPage = 0x02
+	nvmeAdminIdentify   = 0x06
+	nvmeLogSmart        = 0x02
+	nvmeIdentifyCtrl    = 0x01
+	nvmeNSIDAll         = 0xFFFFFFFF
+)
+
+// nvmeHealthPlatform reads the SMART / Health log and Identify Controller
+// data straight from the driver. Needs root (CAP_SYS_ADMIN).
+func nvmeHealthPlatform(device string) (*HealthResult, error) {
+	f, err := os.Open(device)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	logBuf := make([]byte, nvmeSmartLogSize)
+	numd := uint32(nvmeSmartLogSize/4 - 1) // dwords, 0-based
+	if err := nvmeAdmin(f, &nvmeAdminCmd{
+		opcode: nvmeAdminGetLogPage,
+		nsid:   nvmeNSIDAll,
+		cdw10:  numd<<16 | nvmeLogSmart,
+	}, logBuf); err != nil {
+		return nil, err
+	}
+	log, err := decodeNVMeSmartLog(logBuf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identify only adds model/serial; health is still valid without it.
+	var id nvmeIdentify
+	idBuf := make([]byte, nvmeIdentifySize)
+	if nvmeAdmin(f, &nvmeAdminCmd{opcode: nvmeAdminIdentify, cdw10: nvmeIdentifyCtrl}, idBuf) == nil {
+		id, _ = decodeNVMeIdentify(idBuf)
+	}
+
+	result := nvmeHealthResult(log, id)
+	return &result, nil
+}
+
+// nvmeAdmin issues an admin command that transfers data into buf.
+func nvmeAdmin(f *os.File, cmd *nvmeAdminCmd, buf []byte) error {
+	cmd.addr = uint64(uintptr(unsafe.Pointer(&buf[0])))
+	cmd.dataLen = uint32(len(buf))
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), nvmeIoctlAdminCmd, uintptr(unsafe.Pointer(cmd)))
+	runtime.KeepAlive(buf)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
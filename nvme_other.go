@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+func nvmeHealthPlatform(device string) (*HealthResult, error) {
+	return nil, errors.New("native NVMe health is only supported on Linux")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeNVMeSmartLog(t *testing.T) {
+	tests := []struct {
+		file string
+		want nvmeSmartLog
+	}{
+		{"970evoplus_smart_log.bin", nvmeSmartLog{
+			TemperatureK: 314, AvailableSpare: 100, SpareThreshold: 10, PercentageUsed: 3,
+			DataUnitsRead: 41231879, DataUnitsWritten: 52619331, PowerCycles: 1093, PowerOnHours: 7321,
+			UnsafeShutdowns: 61, ErrorLogEntries: 2019,
+		}},
+		{
+			// spare below threshold and reliability degraded, past rated
+			// endurance, data units read past 2^64
+			"worn_smart_log.bin", nvmeSmartLog{
+				CriticalWarning: 0x05, TemperatureK: 358, AvailableSpare: 4, SpareThreshold: 10, PercentageUsed: 255,
+				DataUnitsRead: math.MaxUint64, DataUnitsWritten: 98765432109, PowerCycles: 18, PowerOnHours: 61320,
+				UnsafeShutdowns: 3, MediaErrors: 27, ErrorLogEntries: 412, WarnTempMinutes: 95, CritTempMinutes: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := decodeNVMeSmartLog(readFixture(t, filepath.Join("nvme", tt.file)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeNVMeSmartLog:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := decodeNVMeSmartLog(make([]byte, 100)); err == nil {
+		t.Error("short log page decoded without error")
+	}
+}
+
+func TestDecodeNVMeIdentify(t *testing.T) {
+	b := readFixture(t, "nvme/970evoplus_identify.bin")
+	want := nvmeIdentify{
+		Serial: "S4EWNX0R123456A", Model: "Samsung SSD 970 EVO Plus 1TB", Firmware: "2B2QEXM7",
+		WarnTempK: 358, CritTempK: 358,
+	}
+	got, err := decodeNVMeIdentify(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("decodeNVMeIdentify:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Some firmware pads with NULs instead of spaces.
+	for i := 24 + len(want.Model); i < 64; i++ {
+		b[i] = 0
+	}
+	if got, _ := decodeNVMeIdentify(b); got.Model != want.Model {
+		t.Errorf("NUL-padded model = %q, want %q", got.Model, want.Model)
+	}
+
+	if _, err := decodeNVMeIdentify(b[:512]); err == nil {
+		t.Error("short identify structure decoded without error")
+	}
+}
+
+func TestNVMeHealthResult(t *testing.T) {
+	log, err := decodeNVMeSmartLog(readFixture(t, "nvme/worn_smart_log.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := decodeNVMeIdentify(readFixture(t, "nvme/970evoplus_identify.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := nvmeHealthResult(log, id)
+	if r.Status != "CRITICAL" {
+		t.Errorf("Status = %q, want CRITICAL", r.Status)
+	}
+	if r.Temperature != 85 {
+		t.Errorf("Temperature = %d, want 85", r.Temperature)
+	}
+	if r.PercentageUsed == nil || *r.PercentageUsed != 255 {
+		t.Errorf("PercentageUsed = %v, want 255", r.PercentageUsed)
+	}
+	if r.WearLevel != 0 {
+		t.Errorf("WearLevel = %d, want 0", r.WearLevel)
+	}
+	if r.MediaErrors != 27 || r.PowerOnHours != 61320 {
+		t.Errorf("MediaErrors, PowerOnHours = %d, %d, want 27, 61320", r.MediaErrors, r.PowerOnHours)
+	}
+}
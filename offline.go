@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// analyzeSmartFiles prints a health report for each saved smartctl dump
+// (`smartctl -a -j` or plain `smartctl -a` output).
+func analyzeSmartFiles(files []string) {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  Error: %v\n", err)
+			continue
+		}
+		result, diskType := parseSmartDump(data)
+		activeHealthPolicy.apply(&result, diskType)
+
+		name := result.Model
+		if name == "" {
+			name = "Unknown"
+		}
+		printDiskSectionHeader(DiskInfo{
+			Device: filepath.Base(file), Name: name, DiskType: diskType,
+			Interface: "smartctl dump", Serial: result.Serial,
+		})
+		if result.Serial != "" {
+			fmt.Printf("  %sSerial: %s%s\n", colorDim, result.Serial, colorReset)
+		}
+		printHealthReport(result)
+	}
+}
+
+// parseSmartDump parses saved smartctl output and guesses the disk type
+// (nvme, ssd, hdd or "" when the dump doesn't say).
+func parseSmartDump(data []byte) (HealthResult, string) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		result := parseSmartctlJSON(data)
+		if result == nil {
+			return HealthResult{Status: "UNKNOWN", Message: "No SMART data in dump"}, ""
+		}
+		var meta struct {
+			Device struct {
+				Protocol string `json:"protocol"`
+			} `json:"device"`
+			RotationRate *int `json:"rotation_rate"`
+		}
+		_ = json.Unmarshal(data, &meta)
+		switch {
+		case strings.EqualFold(meta.Device.Protocol, "NVMe"):
+			return *result, "nvme"
+		case meta.RotationRate == nil:
+			return *result, ""
+		case *meta.RotationRate > 0:
+			return *result, "hdd"
+		default:
+			return *result, "ssd"
+		}
+	}
+
+	text := string(data)
+	result := parseSmartctlText(text)
+	switch {
+	case strings.Contains(text, "NVMe Log"):
+		return result, "nvme"
+	case strings.Contains(text, "Solid State Device"):
+		return result, "ssd"
+	case regexp.MustCompile(`(?m)^Rotation Rate:\s+\d+ rpm`).MatchString(text):
+		return result, "hdd"
+	}
+	return result, ""
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// benchOptions are the benchmark settings shared by every disk in a run.
+type benchOptions struct {
+	Speed    bool
+	IOPS     bool
+	Size     int64 // sequential test size, 0 = automatic
+	Duration int   // seconds per IOPS test
+	Sync     bool
+	Streams  int // sequential streams
+	SeqQD    int // requests in flight per sequential stream
+}
+
+// benchRun holds one disk's results from a solo or parallel run.
+type benchRun struct {
+	Speed  SpeedResult
+	IOPS   []IOPSResult
+	Device *DeviceIOResult // block device counters, nil where unavailable
+}
+
+// parallelJob is a disk taking part in a --parallel run.
+type parallelJob struct {
+	disk  DiskInfo
+	dir   string
+	label string
+}
+
+// diskActivity tracks one disk's current phase and bytes moved, for the
+// combined status line.
+type diskActivity struct {
+	label string
+	bytes atomic.Int64
+	mu    sync.Mutex
+	phase string
+}
+
+func (a *diskActivity) setPhase(phase string) {
+	a.mu.Lock()
+	a.phase = phase
+	a.mu.Unlock()
+}
+
+func (a *diskActivity) addBytes(n int) {
+	a.bytes.Add(int64(n))
+}
+
+func (a *diskActivity) currentPhase() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.phase
+}
+
+// parallelStatusInterval is how often the combined status line is printed.
+const parallelStatusInterval = 5 * time.Second
+
+// statusPerLine is how many disks share one line of the status output.
+const statusPerLine = 4
+
+// shortPhase abbreviates a benchmark phase for the status line:
+// "Random Write QD4" becomes "rnd W QD4".
+func shortPhase(phase string) string {
+	r := strings.NewReplacer("Sequential", "seq", "Random", "rnd", "Write", "W", "Read", "R")
+	return r.Replace(phase)
+}
+
+// watchProgress prints every disk's current phase and rate, plus the
+// total, every interval until stop is closed. Lines are printed rather than
+// redrawn so the output stays readable with many disks and when redirected
+// to a file.
+func watchProgress(acts []*diskActivity, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	start := time.Now()
+	last := make([]int64, len(acts))
+	lastTime := start
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			dt := now.Sub(lastTime).Seconds()
+			lastTime = now
+			var parts []string
+			var totalMBPS, totalIOPS float64
+			for i, a := range acts {
+				b := a.bytes.Load()
+				delta := float64(b - last[i])
+				last[i] = b
+				phase := a.currentPhase()
+				switch {
+				case phase == "":
+					parts = append(parts, a.label+" idle")
+				case strings.HasPrefix(phase, "Random"):
+					iops := delta / iopsBlockSize / dt
+					totalIOPS += iops
+					parts = append(parts, fmt.Sprintf("%s %s %s IOPS", a.label, shortPhase(phase), formatNumber(int64(iops))))
+				default:
+					mbps := delta / dt / (1024 * 1024)
+					totalMBPS += mbps
+					parts = append(parts, fmt.Sprintf("%s %s %s MB/s", a.label, shortPhase(phase), formatFloat(mbps, 0)))
+				}
+			}
+			if len(acts) > 1 {
+				var total []string
+				if totalMBPS > 0 {
+					total = append(total, formatFloat(totalMBPS, 0)+" MB/s")
+				}
+				if totalIOPS > 0 {
+					total = append(total, formatNumber(int64(totalIOPS))+" IOPS")
+				}
+				if len(total) > 0 {
+					parts = append(parts, colorBold+"total "+strings.Join(total, ", ")+colorReset)
+				}
+			}
+			elapsed := now.Sub(start).Round(time.Second)
+			for i := 0; i < len(parts); i += statusPerLine {
+				end := min(i+statusPerLine, len(parts))
+				prefix := fmt.Sprintf("%s[%6s]%s", colorDim, elapsed, colorReset)
+				if i > 0 {
+					prefix = strings.Repeat(" ", 8)
+				}
+				fmt.Printf("  %s %s\n", prefix, strings.Join(parts[i:end], " | "))
+			}
+		}
+	}
+}
+
+// runJobs benchmarks the given disks at the same time, with the combined
+// status line in place of the per-benchmark progress output.
+func runJobs(jobs []parallelJob, opts benchOptions) []benchRun {
+	acts := make([]*diskActivity, len(jobs))
+	for i, j := range jobs {
+		acts[i] = &diskActivity{label: j.label}
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go watchProgress(acts, parallelStatusInterval, stop, done)
+
+	runs := make([]benchRun, len(jobs))
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			devStats := startDiskStats(j.dir, acts[i])
+			if opts.Speed {
+				size := speedTestSize(j.disk, j.dir, opts.Size)
+				runs[i].Speed = speedTest(j.dir, size, defaultBlockSize, opts.Streams, opts.SeqQD, devStats)
+			}
+			if opts.IOPS {
+				runs[i].IOPS = iopsTest(j.dir, opts.Duration, opts.Sync, j.disk, devStats)
+			}
+			runs[i].Device = devStats.finish()
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-done
+	return runs
+}
+
+// runParallel benchmarks each disk on its own and then all of them at once,
+// and reports how much each disk slowed down when sharing the bus. A drop
+// shows an HBA, expander, backplane or PCIe uplink that cannot feed every
+// disk at full speed.
+func runParallel(disks []DiskInfo, opts benchOptions) {
+	var jobs []parallelJob
+	seen := make(map[string]bool)
+	for _, d := range disks {
+		dir := benchDir(d, true)
+		if dir == "" || seen[dir] {
+			continue // two targets on one filesystem would share the test files
+		}
+		seen[dir] = true
+		jobs = append(jobs, parallelJob{disk: d, dir: dir, label: filepath.Base(d.Device)})
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	sep := "━━"
+	if !useUnicode {
+		sep = "=="
+	}
+	fmt.Println()
+	fmt.Printf("  %s%s Parallel benchmark: %d disks %s%s\n", colorBold, sep, len(jobs), sep, colorReset)
+	if len(jobs) < 2 {
+		fmt.Printf("  %sWarning: only one writable disk selected, nothing runs in parallel.%s\n",
+			colorYellow, colorReset)
+	}
+	devices := make(map[string]bool)
+	for _, j := range jobs {
+		if devices[j.disk.Device] {
+			fmt.Printf("  %sWarning: several targets are on %s; they compete for the same disk.%s\n",
+				colorYellow, j.disk.Device, colorReset)
+		}
+		devices[j.disk.Device] = true
+	}
+	fmt.Println()
+
+	progressOut = io.Discard
+	defer func() { progressOut = os.Stdout }()
+
+	solo := make([]benchRun, len(jobs))
+	for i, j := range jobs {
+		fmt.Printf("  %sSolo run: %s (%s)%s\n", colorBold, j.disk.Device, j.dir, colorReset)
+		solo[i] = runJobs(jobs[i:i+1], opts)[0]
+	}
+	fmt.Println()
+	fmt.Printf("  %sParallel run: %d disks%s\n", colorBold, len(jobs), colorReset)
+	par := runJobs(jobs, opts)
+	fmt.Println()
+
+	printParallelReport(jobs, solo, par, opts)
+	for i, j := range jobs {
+		if par[i].Device != nil {
+			fmt.Printf("  %sParallel run: %s%s\n", colorBold, j.disk.Device, colorReset)
+			printDeviceIOReport(par[i].Device)
+		}
+	}
+}
+
+// parallelChange formats the change from a solo to a parallel result,
+// coloured by how far the disk slowed down.
+func parallelChange(solo, par float64) string {
+	if solo <= 0 {
+		return "-"
+	}
+	pct := (par - solo) / solo * 100
+	color := ""
+	switch {
+	case pct <= -20:
+		color = colorRed
+	case pct <= -5:
+		color = colorYellow
+	}
+	return fmt.Sprintf("%s%+.0f%%%s", color, pct, colorReset)
+}
+
+// parallelIOPS returns the QD4 result, the one most affected by a shared bus.
+func parallelIOPS(results []IOPSResult) IOPSResult {
+	for _, r := range results {
+		if r.QueueDepth == 4 {
+			return r
+		}
+	}
+	return IOPSResult{}
+}
+
+// printParallelReport prints solo against parallel results per disk, the
+// totals, and a warning when the aggregate falls well short of the sum of
+// the solo runs.
+func printParallelReport(jobs []parallelJob, solo, par []benchRun, opts benchOptions) {
+	headers := []string{"Device", "Read Solo", "Read Parallel", "Change", "Write Solo", "Write Parallel", "Change"}
+	aligns := []byte{'l', 'r', 'r', 'r', 'r', 'r', 'r'}
+
+	type totals struct{ soloR, parR, soloW, parW float64 }
+	table := func(title string, get func(benchRun) (read, write float64), prec int) totals {
+		var t totals
+		rows := make([][]string, 0, len(jobs)+1)
+		for i, j := range jobs {
+			sr, sw := get(solo[i])
+			pr, pw := get(par[i])
+			t.soloR += sr
+			t.parR += pr
+			t.soloW += sw
+			t.parW += pw
+			rows = append(rows, []string{j.disk.Device,
+				formatFloat(sr, prec), formatFloat(pr, prec), parallelChange(sr, pr),
+				formatFloat(sw, prec), formatFloat(pw, prec), parallelChange(sw, pw)})
+		}
+		if len(jobs) > 1 {
+			rows = append(rows, []string{colorBold + "Total" + colorReset,
+				formatFloat(t.soloR, prec), formatFloat(t.parR, prec), parallelChange(t.soloR, t.parR),
+				formatFloat(t.soloW, prec), formatFloat(t.parW, prec), parallelChange(t.soloW, t.parW)})
+		}
+		fmt.Printf("  %s%s%s\n", colorBold, title, colorReset)
+		printTable(headers, rows, aligns)
+		fmt.Println()
+		return t
+	}
+
+	var bottleneck []string
+	check := func(what string, soloSum, parSum float64) {
+		if soloSum <= 0 || len(jobs) < 2 {
+			return
+		}
+		if pct := parSum / soloSum * 100; pct < 80 {
+			bottleneck = append(bottleneck, fmt.Sprintf("%s reached %.0f%% of the solo total", what, pct))
+		}
+	}
+
+	if opts.Speed {
+		t := table("Sequential (MB/s)", func(r benchRun) (float64, float64) {
+			return r.Speed.ReadMBPS, r.Speed.WriteMBPS
+		}, 1)
+		check("sequential read", t.soloR, t.parR)
+		check("sequential write", t.soloW, t.parW)
+	}
+	if opts.IOPS {
+		t := table("Random 4K QD4 (IOPS)", func(r benchRun) (float64, float64) {
+			q := parallelIOPS(r.IOPS)
+			return q.ReadIOPS, q.WriteIOPS
+		}, 0)
+		check("random read", t.soloR, t.parR)
+		check("random write", t.soloW, t.parW)
+	}
+
+	if len(jobs) < 2 {
+		return
+	}
+	if len(bottleneck) == 0 {
+		fmt.Printf("  %sThe disks kept at least 80%% of their solo speed together - no shared bottleneck found.%s\n",
+			colorGreen, colorReset)
+	} else {
+		fmt.Printf("  %sWarning: %s.%s\n", colorYellow, strings.Join(bottleneck, "; "), colorReset)
+		fmt.Printf("  %sThe disks share a bottleneck: check the HBA, SAS expander, backplane or the controller's PCIe link.%s\n",
+			colorYellow, colorReset)
+	}
+	fmt.Printf("  %sTotals add each disk's average; disks that finish early leave the bus to the others.%s\n",
+		colorDim, colorReset)
+	fmt.Println()
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// healthRule grades one SMART attribute. Warn and Fail are inclusive limits:
+// a value at or above the limit trips it, except for wear_level (remaining
+// life) where a value at or below trips it. OnWarn/OnFail give the overall
+// status the disk is escalated to; empty means the attribute is only marked.
+type healthRule struct {
+	Warn   *float64 `json:"warn,omitempty"`
+	Fail   *float64 `json:"fail,omitempty"`
+	OnWarn string   `json:"on_warn,omitempty"`
+	OnFail string   `json:"on_fail,omitempty"`
+}
+
+// trendRule raises a history alert when an attribute worsens by at least
+// Warn within Window (e.g. "7d", "36h"). For wear_level the drop counts.
+type trendRule struct {
+	Warn   float64 `json:"warn"`
+	Window string  `json:"window"`
+}
+
+// healthPolicy holds the rules checkHealth evaluates SMART data against.
+// The most specific rule for an attribute wins: model, then disk type,
+// then default. Model keys are case-insensitive glob patterns.
+type healthPolicy struct {
+	Default map[string]healthRule            `json:"default"`
+	Types   map[string]map[string]healthRule `json:"types,omitempty"`
+	Models  map[string]map[string]healthRule `json:"models,omitempty"`
+	Trends  map[string]trendRule             `json:"trends,omitempty"`
+}
+
+// policyKeys lists the attributes a policy can grade, in evaluation order.
+var policyKeys = []string{
+	"temperature",
+	"reallocated_sectors",
+	"pending_sectors",
+	"media_errors",
+	"percentage_used",
+	"wear_level",
+	"power_on_hours",
+	"kernel_errors",
+}
+
+// activeHealthPolicy is the policy used by checkHealth.
+var activeHealthPolicy = defaultHealthPolicy()
+
+// defaultHealthPolicy returns the built-in thresholds.
+func defaultHealthPolicy() *healthPolicy {
+	return &healthPolicy{
+		Default: map[string]healthRule{
+			"temperature":         {Warn: limit(71), OnWarn: "WARNING"},
+			"reallocated_sectors": {Warn: limit(1), OnWarn: "WARNING"},
+			"pending_sectors":     {Warn: limit(1)},
+			"media_errors":        {Warn: limit(1), OnWarn: "WARNING"},
+			"percentage_used":     {Warn: limit(80), Fail: limit(95)},
+			"wear_level":          {Warn: limit(19)},
+			"kernel_errors":       {Warn: limit(1), OnWarn: "WARNING"},
+		},
+		Types: map[string]map[string]healthRule{
+			// NVMe drives routinely run hot under load; flag but don't escalate.
+			"nvme": {"temperature": {Warn: limit(71)}},
+		},
+		Trends: map[string]trendRule{
+			"reallocated_sectors": {Warn: 5, Window: "7d"},
+			"pending_sectors":     {Warn: 1, Window: "7d"},
+			"media_errors":        {Warn: 1, Window: "7d"},
+			"wear_level":          {Warn: 5, Window: "30d"},
+			"temperature":         {Warn: 15, Window: "7d"},
+		},
+	}
+}
+
+func limit(v float64) *float64 { return &v }
+
+// loadHealthPolicy reads a JSON policy file on top of the built-in policy.
+// Default rules in the file replace built-in ones attribute by attribute;
+// a type or model section replaces the built-in section of the same name.
+func loadHealthPolicy(file string) (*healthPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := defaultHealthPolicy()
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return p, nil
+}
+
+func (p *healthPolicy) validate() error {
+	check := func(scope string, rules map[string]healthRule) error {
+		for key, r := range rules {
+			if !isPolicyKey(key) {
+				return fmt.Errorf("%s: unknown attribute %q", scope, key)
+			}
+			for _, s := range []string{r.OnWarn, r.OnFail} {
+				switch s {
+				case "", "HEALTHY", "WARNING", "CRITICAL":
+				default:
+					return fmt.Errorf("%s.%s: invalid status %q", scope, key, s)
+				}
+			}
+		}
+		return nil
+	}
+	if err := check("default", p.Default); err != nil {
+		return err
+	}
+	for t, rules := range p.Types {
+		if err := check("types."+t, rules); err != nil {
+			return err
+		}
+	}
+	for key, t := range p.Trends {
+		if !isPolicyKey(key) {
+			return fmt.Errorf("trends: unknown attribute %q", key)
+		}
+		if d, err := parseDuration(t.Window); err != nil || d <= 0 {
+			return fmt.Errorf("trends.%s: bad window %q", key, t.Window)
+		}
+	}
+	for m, rules := range p.Models {
+		if _, err := path.Match(m, ""); err != nil {
+			return fmt.Errorf("models: bad pattern %q", m)
+		}
+		if err := check("models."+m, rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isPolicyKey(key string) bool {
+	for _, k := range policyKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// rule returns the most specific rule for an attribute. Of several matching
+// model patterns the one with the most literal characters wins.
+func (p *healthPolicy) rule(key, diskType, model string) (healthRule, bool) {
+	if model != "" {
+		patterns := make([]string, 0, len(p.Models))
+		for m := range p.Models {
+			patterns = append(patterns, m)
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			li, wi := globSpecificity(patterns[i])
+			lj, wj := globSpecificity(patterns[j])
+			if li != lj {
+				return li > lj
+			}
+			if wi != wj {
+				return wi < wj
+			}
+			return patterns[i] < patterns[j]
+		})
+		for _, m := range patterns {
+			ok, _ := path.Match(strings.ToUpper(m), strings.ToUpper(model))
+			if r, found := p.Models[m][key]; ok && found {
+				return r, true
+			}
+		}
+	}
+	if r, ok := p.Types[strings.ToLower(diskType)][key]; ok {
+		return r, true
+	}
+	r, ok := p.Default[key]
+	return r, ok
+}
+
+// globSpecificity counts the literal characters and the wildcards (*, ? and
+// [...] classes) of a model pattern. More literals, then fewer wildcards,
+// make a pattern more specific.
+func globSpecificity(pattern string) (literal, wild int) {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+			wild++
+		case '[':
+			wild++
+			if j := strings.IndexByte(pattern[i+1:], ']'); j >= 0 {
+				i += j + 1
+			}
+		case '\\':
+			i++
+			literal++
+		default:
+			literal++
+		}
+	}
+	return literal, wild
+}
+
+// grade returns OK, WARN or FAIL for a value.
+func (r healthRule) grade(key string, val float64) string {
+	trips := func(l *float64) bool {
+		if l == nil {
+			return false
+		}
+		if key == "wear_level" {
+			return val <= *l
+		}
+		return val >= *l
+	}
+	switch {
+	case trips(r.Fail):
+		return "FAIL"
+	case trips(r.Warn):
+		return "WARN"
+	}
+	return "OK"
+}
+
+// apply grades the attributes of a SMART result and escalates its status.
+func (p *healthPolicy) apply(r *HealthResult, diskType string) {
+	grades := make(map[string]string)
+	for _, key := range policyKeys {
+		val, ok := healthMetric(r, key)
+		if !ok {
+			continue
+		}
+		rule, ok := p.rule(key, diskType, r.Model)
+		if !ok {
+			continue
+		}
+		g := rule.grade(key, val)
+		grades[key] = g
+		switch {
+		case g == "FAIL" && rule.OnFail != "":
+			r.Status = worseStatus(r.Status, rule.OnFail)
+		case g == "WARN" && rule.OnWarn != "":
+			r.Status = worseStatus(r.Status, rule.OnWarn)
+		}
+	}
+	for i, a := range r.Attributes {
+		if g, ok := grades[a.Key]; ok {
+			r.Attributes[i].Status = g
+		}
+	}
+}
+
+// healthMetric returns the value of a policy attribute and whether it was reported.
+func healthMetric(r *HealthResult, key string) (float64, bool) {
+	switch key {
+	case "temperature":
+		return float64(r.Temperature), r.Temperature > 0
+	case "reallocated_sectors":
+		return float64(r.ReallocatedSectors), true
+	case "pending_sectors":
+		return float64(r.PendingSectors), true
+	case "media_errors":
+		return float64(r.MediaErrors), true
+	case "percentage_used":
+		if r.PercentageUsed == nil {
+			return 0, false
+		}
+		return float64(*r.PercentageUsed), true
+	case "wear_level":
+		return float64(r.WearLevel), r.WearLevel > 0
+	case "power_on_hours":
+		return float64(r.PowerOnHours), r.PowerOnHours > 0
+	case "kernel_errors":
+		if r.KernelErrors == nil {
+			return 0, false
+		}
+		return float64(r.KernelErrors.Total), true
+	}
+	return 0, false
+}
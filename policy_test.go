@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestHealthPolicyModelSpecificity(t *testing.T) {
+	p := defaultHealthPolicy()
+	p.Models = map[string]map[string]healthRule{
+		"*":                {"temperature": {Warn: limit(50)}},
+		"samsung*":         {"temperature": {Warn: limit(60)}},
+		"Samsung SSD 970*": {"temperature": {Warn: limit(80)}},
+		"Samsung SSD 9?0*": {"temperature": {Warn: limit(75)}},
+	}
+	tests := []struct {
+		model string
+		warn  float64
+	}{
+		{"Samsung SSD 970 EVO Plus 1TB", 80},
+		{"Samsung SSD 980 PRO 1TB", 75},
+		{"SAMSUNG MZVL2512HCJQ", 60},
+		{"WD_BLACK SN850X 2000GB", 50},
+	}
+	for _, tt := range tests {
+		r, ok := p.rule("temperature", "nvme", tt.model)
+		if !ok || r.Warn == nil {
+			t.Errorf("%s: no rule", tt.model)
+			continue
+		}
+		if *r.Warn != tt.warn {
+			t.Errorf("%s: warn = %g, want %g", tt.model, *r.Warn, tt.warn)
+		}
+	}
+
+	// Without a model the type rule applies.
+	if r, _ := p.rule("temperature", "nvme", ""); *r.Warn != 71 {
+		t.Errorf("no model: warn = %g, want 71", *r.Warn)
+	}
+}
+
+func TestGlobSpecificity(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		literal, wild int
+	}{
+		{"*", 0, 1},
+		{"Samsung SSD 970*", 15, 1},
+		{"ST[0-9]000*", 5, 2},
+		{`WDC\*`, 4, 0},
+	}
+	for _, tt := range tests {
+		if l, w := globSpecificity(tt.pattern); l != tt.literal || w != tt.wild {
+			t.Errorf("globSpecificity(%q) = %d, %d, want %d, %d", tt.pattern, l, w, tt.literal, tt.wild)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// profile is a named set of workloads for one kind of application, with
+// the results it needs to run well.
+type profile struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Tests       []workload           `json:"tests"`
+	Require     []profileRequirement `json:"require"`
+}
+
+// profileRequirement grades one metric of one test: GOOD if it meets Good,
+// FAIR if it meets Fair, otherwise POOR. Latencies must be at or below the
+// limits, throughput at or above.
+type profileRequirement struct {
+	Test   string  `json:"test"`
+	Metric string  `json:"metric"` // iops, mbps, avg_latency_us, p99_latency_us
+	Good   float64 `json:"good"`
+	Fair   float64 `json:"fair"`
+}
+
+// profileFile is the format of --profile-file.
+type profileFile struct {
+	Profiles []profile `json:"profiles"`
+}
+
+// builtinProfiles are the workloads available without a profile file.
+var builtinProfiles = []profile{
+	{
+		Name:        "oltp",
+		Description: "OLTP database: 8K random 70/30 with fsync at QD16",
+		Tests: []workload{
+			{Name: "oltp", Pattern: "random", BlockSizes: []string{"8K"}, ReadPct: 70, QueueDepth: 16, Fsync: true},
+		},
+		Require: []profileRequirement{
+			{"oltp", "iops", 20000, 2000},
+			{"oltp", "p99_latency_us", 2000, 20000},
+		},
+	},
+	{
+		Name:        "vm",
+		Description: "Virtual machine images: 4K-64K random 70/30 at QD8",
+		Tests: []workload{
+			{Name: "vm", Pattern: "random", BlockSizes: []string{"4K", "8K", "16K", "32K", "64K"}, ReadPct: 70, QueueDepth: 8},
+		},
+		Require: []profileRequirement{
+			{"vm", "iops", 20000, 3000},
+			{"vm", "p99_latency_us", 5000, 30000},
+		},
+	},
+	{
+		Name:        "media",
+		Description: "Media streaming: 1M sequential reads, 4 streams",
+		Tests: []workload{
+			{Name: "media", Pattern: "sequential", BlockSizes: []string{"1M"}, ReadPct: 100, QueueDepth: 1, Threads: 4},
+		},
+		Require: []profileRequirement{
+			{"media", "mbps", 500, 150},
+			{"media", "p99_latency_us", 50000, 200000},
+		},
+	},
+	{
+		Name:        "backup",
+		Description: "Backup target: large sequential writes",
+		Tests: []workload{
+			{Name: "backup", Pattern: "sequential", BlockSizes: []string{"4M"}, ReadPct: 0, QueueDepth: 1, Threads: 2},
+		},
+		Require: []profileRequirement{
+			{"backup", "mbps", 500, 150},
+		},
+	},
+	{
+		Name:        "build",
+		Description: "Software builds: small random reads and mixed small-file I/O",
+		Tests: []workload{
+			{Name: "build-read", Pattern: "random", BlockSizes: []string{"4K"}, ReadPct: 100, QueueDepth: 1},
+			{Name: "build-mixed", Pattern: "random", BlockSizes: []string{"4K", "16K"}, ReadPct: 50, QueueDepth: 4},
+		},
+		Require: []profileRequirement{
+			{"build-read", "iops", 8000, 1000},
+			{"build-read", "p99_latency_us", 1000, 10000},
+			{"build-mixed", "iops", 20000, 3000},
+		},
+	},
+}
+
+// profileMetric returns a result metric by requirement name.
+func profileMetric(r workloadResult, metric string) (value float64, lowerIsBetter bool) {
+	switch metric {
+	case "iops":
+		return r.IOPS, false
+	case "mbps":
+		return r.MBPS, false
+	case "avg_latency_us":
+		return r.AvgLatUS, true
+	case "p99_latency_us":
+		return r.P99LatUS, true
+	}
+	return 0, false
+}
+
+func (p profile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("profile without a name")
+	}
+	if len(p.Tests) == 0 {
+		return fmt.Errorf("%s: no tests", p.Name)
+	}
+	tests := make(map[string]bool)
+	for _, t := range p.Tests {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		tests[t.Name] = true
+	}
+	for _, r := range p.Require {
+		if !tests[r.Test] {
+			return fmt.Errorf("%s: requirement for unknown test %q", p.Name, r.Test)
+		}
+		switch r.Metric {
+		case "iops", "mbps":
+			if r.Good < r.Fair {
+				return fmt.Errorf("%s: %s %s: good (%g) must be at least fair (%g)", p.Name, r.Test, r.Metric, r.Good, r.Fair)
+			}
+		case "avg_latency_us", "p99_latency_us":
+			if r.Good > r.Fair {
+				return fmt.Errorf("%s: %s %s: good (%g) must be at most fair (%g)", p.Name, r.Test, r.Metric, r.Good, r.Fair)
+			}
+		default:
+			return fmt.Errorf("%s: unknown metric %q", p.Name, r.Metric)
+		}
+	}
+	return nil
+}
+
+// loadProfiles reads user profiles; they replace built-in profiles of the
+// same name.
+func loadProfiles(file string) ([]profile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var pf profileFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, p := range pf.Profiles {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return pf.Profiles, nil
+}
+
+// availableProfiles merges the built-in and user profiles, sorted by name.
+func availableProfiles(user []profile) []profile {
+	byName := make(map[string]profile)
+	for _, p := range builtinProfiles {
+		byName[p.Name] = p
+	}
+	for _, p := range user {
+		byName[p.Name] = p
+	}
+	all := make([]profile, 0, len(byName))
+	for _, p := range byName {
+		all = append(all, p)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// selectProfiles resolves a comma-separated --profile list.
+func selectProfiles(names string, available []profile) ([]profile, error) {
+	var selected []profile
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, p := range available {
+			if p.Name == name {
+				selected = append(selected, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown profile %q (see --profile list)", name)
+		}
+	}
+	return selected, nil
+}
+
+// printProfileList prints the available profiles and their tests.
+func printProfileList(profiles []profile) {
+	headers := []string{"Profile", "Description", "Tests"}
+	aligns := []byte{'l', 'l', 'l'}
+	rows := make([][]string, len(profiles))
+	for i, p := range profiles {
+		tests := make([]string, len(p.Tests))
+		for j, t := range p.Tests {
+			tests[j] = t.describe()
+		}
+		rows[i] = []string{p.Name, p.Description, strings.Join(tests, "; ")}
+	}
+	printTable(headers, rows, aligns)
+}
+
+// runProfiles runs the profiles' tests on one disk and prints a verdict
+// for each profile.
+func runProfiles(disk DiskInfo, testDir string, profiles []profile, opts benchOptions, mon benchObserver) {
+	testFile := filepath.Join(testDir, ".diskbench_profile")
+	registerCleanup(testFile)
+	defer func() {
+		os.Remove(testFile)
+		unregisterCleanup(testFile)
+	}()
+
+	fileSize := opts.Size
+	if fileSize <= 0 {
+		fileSize = autoIOPSFileSize(disk)
+	}
+	fileSize = checkAvailableSpace(testDir, fileSize)
+	fmt.Fprintf(progressOut, "  Preparing profile test file (%s)...", formatSize(fileSize))
+	if err := createTestFile(testFile, fileSize); err != nil {
+		fmt.Fprintf(progressOut, " error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(progressOut, " done.\n")
+
+	for _, p := range profiles {
+		fmt.Println()
+		fmt.Printf("  %sProfile: %s%s %s- %s%s\n", colorBold, p.Name, colorReset, colorDim, p.Description, colorReset)
+		results := make(map[string]workloadResult)
+		var mode achievedMode
+		for _, t := range p.Tests {
+			r := runWorkload(t, testFile, fileSize, opts.Duration, mon)
+			fmt.Fprintf(progressOut, "  %s (%s): %s IOPS, %s MB/s\n", t.Name, t.describe(),
+				formatNumber(int64(r.IOPS)), formatFloat(r.MBPS, 1))
+			results[t.Name] = r
+			mode.note(r.IOMode, r.IOFallback)
+		}
+		fmt.Println()
+		fmt.Printf("  %sNote: %s%s\n", colorDim, ioModeNote(mode.get()), colorReset)
+		printProfileReport(p, results)
+	}
+}
+
+// gradeRequirement grades a measured value against a requirement.
+func gradeRequirement(r profileRequirement, value float64, lowerIsBetter bool) string {
+	meets := func(limit float64) bool {
+		if lowerIsBetter {
+			return value <= limit
+		}
+		return value >= limit
+	}
+	switch {
+	case meets(r.Good):
+		return "GOOD"
+	case meets(r.Fair):
+		return "FAIR"
+	}
+	return "POOR"
+}
+
+// profileVerdict returns the worst grade of all requirements.
+func profileVerdict(grades []string) string {
+	verdict := "GOOD"
+	for _, g := range grades {
+		if g == "POOR" || (g == "FAIR" && verdict == "GOOD") {
+			verdict = g
+		}
+	}
+	return verdict
+}
+
+func verdictColor(v string) string {
+	switch v {
+	case "GOOD":
+		return colorGreen
+	case "FAIR":
+		return colorYellow
+	case "POOR":
+		return colorRed
+	}
+	return ""
+}
+
+// printProfileReport prints a profile's results and its fitness verdict.
+func printProfileReport(p profile, results map[string]workloadResult) {
+	headers := []string{"Test", "Workload", "IOPS", "MB/s", "Avg Lat (us)", "p99 Lat (us)"}
+	aligns := []byte{'l', 'l', 'r', 'r', 'r', 'r'}
+	var rows [][]string
+	for _, t := range p.Tests {
+		r := results[t.Name]
+		rows = append(rows, []string{t.Name, t.describe(), formatFloat(r.IOPS, 0), formatFloat(r.MBPS, 1),
+			formatFloat(r.AvgLatUS, 1), formatFloat(r.P99LatUS, 0)})
+	}
+	printTable(headers, rows, aligns)
+	fmt.Println()
+
+	var grades []string
+	var errs int64
+	for _, r := range results {
+		errs += r.Errors
+	}
+	if len(p.Require) > 0 {
+		headers = []string{"Check", "Measured", "Good", "Fair", "Grade"}
+		aligns = []byte{'l', 'r', 'r', 'r', 'c'}
+		rows = nil
+		for _, req := range p.Require {
+			value, lower := profileMetric(results[req.Test], req.Metric)
+			grade := gradeRequirement(req, value, lower)
+			grades = append(grades, grade)
+			op := ">="
+			if lower {
+				op = "<="
+			}
+			rows = append(rows, []string{req.Test + " " + req.Metric, formatFloat(value, 0),
+				op + " " + formatFloat(req.Good, 0), op + " " + formatFloat(req.Fair, 0),
+				verdictColor(grade) + grade + colorReset})
+		}
+		printTable(headers, rows, aligns)
+		fmt.Println()
+	}
+	if errs > 0 {
+		grades = append(grades, "POOR")
+		fmt.Printf("  %sWarning: %d I/O error(s) during the tests%s\n", colorRed, errs, colorReset)
+	}
+	if len(grades) == 0 {
+		return
+	}
+	v := profileVerdict(grades)
+	fmt.Printf("  Verdict for %s: %s%s%s\n", p.Name, verdictColor(v), v, colorReset)
+	fmt.Println()
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func diskPropsPlatform(device string) *DiskProps {
+	return nil // queue properties are read from Linux sysfs
+}
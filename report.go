@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -95,6 +96,17 @@ var speedRatings = map[string][]ratingThreshold{
 	"hdd":  {{150, "Excellent"}, {100, "Good"}, {50, "Fair"}, {0, "Slow"}},
 	"usb":  {{300, "Excellent"}, {100, "Good"}, {30, "Fair"}, {0, "Slow"}},
 	"nfs":  {{500, "Excellent"}, {100, "Good"}, {50, "Fair"}, {0, "Slow"}},
+	"smb":  {{500, "Excellent"}, {100, "Good"}, {50, "Fair"}, {0, "Slow"}},
+
+	"cephfs":    {{1000, "Excellent"}, {300, "Good"}, {100, "Fair"}, {0, "Slow"}},
+	"glusterfs": {{500, "Excellent"}, {200, "Good"}, {50, "Fair"}, {0, "Slow"}},
+	"lustre":    {{2000, "Excellent"}, {1000, "Good"}, {300, "Fair"}, {0, "Slow"}},
+	"9p":        {{300, "Excellent"}, {100, "Good"}, {30, "Fair"}, {0, "Slow"}},
+	"virtiofs":  {{2000, "Excellent"}, {1000, "Good"}, {300, "Fair"}, {0, "Slow"}},
+	"fuse":      {{200, "Excellent"}, {50, "Good"}, {10, "Fair"}, {0, "Slow"}},
+	"tmpfs":     {{5000, "Excellent"}, {2000, "Good"}, {1000, "Fair"}, {0, "Slow"}},
+	"overlay":   {{500, "Excellent"}, {300, "Good"}, {100, "Fair"}, {0, "Slow"}},
+	"zram":      {{3000, "Excellent"}, {1500, "Good"}, {500, "Fair"}, {0, "Slow"}},
 }
 
 var iopsRatings = map[string][]ratingThreshold{
@@ -103,6 +115,17 @@ var iopsRatings = map[string][]ratingThreshold{
 	"hdd":  {{200, "Excellent"}, {100, "Good"}, {50, "Fair"}, {0, "Slow"}},
 	"usb":  {{5000, "Excellent"}, {1000, "Good"}, {100, "Fair"}, {0, "Slow"}},
 	"nfs":  {{10000, "Excellent"}, {1000, "Good"}, {100, "Fair"}, {0, "Slow"}},
+	"smb":  {{10000, "Excellent"}, {1000, "Good"}, {100, "Fair"}, {0, "Slow"}},
+
+	"cephfs":    {{20000, "Excellent"}, {5000, "Good"}, {500, "Fair"}, {0, "Slow"}},
+	"glusterfs": {{5000, "Excellent"}, {1000, "Good"}, {100, "Fair"}, {0, "Slow"}},
+	"lustre":    {{50000, "Excellent"}, {10000, "Good"}, {1000, "Fair"}, {0, "Slow"}},
+	"9p":        {{5000, "Excellent"}, {1000, "Good"}, {100, "Fair"}, {0, "Slow"}},
+	"virtiofs":  {{50000, "Excellent"}, {10000, "Good"}, {1000, "Fair"}, {0, "Slow"}},
+	"fuse":      {{2000, "Excellent"}, {500, "Good"}, {50, "Fair"}, {0, "Slow"}},
+	"tmpfs":     {{500000, "Excellent"}, {200000, "Good"}, {50000, "Fair"}, {0, "Slow"}},
+	"overlay":   {{50000, "Excellent"}, {10000, "Good"}, {1000, "Fair"}, {0, "Slow"}},
+	"zram":      {{200000, "Excellent"}, {50000, "Good"}, {10000, "Fair"}, {0, "Slow"}},
 }
 
 func rateSpeed(mbps float64, diskType string) string {
@@ -331,6 +354,89 @@ func printDiskList(disks []DiskInfo) {
 	printTable(headers, rows, aligns)
 }
 
+// printDiskDetails prints the sysfs properties of each disk (--list --verbose).
+func printDiskDetails(disks []DiskInfo) {
+	headers := []string{"Device", "Block L/P", "Opt I/O", "Scheduler", "Queue", "Read-ahead", "Rota", "TRIM", "Write Cache", "Link", "Firmware", "WWN"}
+	aligns := []byte{'l', 'r', 'r', 'l', 'r', 'r', 'c', 'r', 'l', 'l', 'l', 'l'}
+	var rows [][]string
+	for _, d := range disks {
+		p := d.Props
+		if p == nil {
+			continue
+		}
+		link := "-"
+		if d.Link != nil {
+			link = d.Link.describe()
+		}
+		rows = append(rows, []string{
+			d.Device,
+			fmt.Sprintf("%d/%d", p.LogicalBlock, p.PhysicalBlock),
+			optionalSize(int64(p.OptimalIO)),
+			p.Scheduler,
+			fmt.Sprint(p.NrRequests),
+			formatSize(int64(p.ReadAheadKB) * 1024),
+			yesNo(p.Rotational),
+			p.trimSummary(),
+			p.WriteCache,
+			link,
+			p.Firmware,
+			p.WWN,
+		})
+	}
+	if len(rows) == 0 {
+		fmt.Printf("  %sNo block device properties available on this platform.%s\n", colorDim, colorReset)
+		return
+	}
+	printTable(headers, rows, aligns)
+}
+
+// summary formats the properties for the per-disk section header.
+func (p *DiskProps) summary() string {
+	parts := []string{fmt.Sprintf("Block %d/%d", p.LogicalBlock, p.PhysicalBlock)}
+	if p.OptimalIO > 0 {
+		parts = append(parts, "Opt I/O "+formatSize(int64(p.OptimalIO)))
+	}
+	if p.Scheduler != "" {
+		parts = append(parts, "Scheduler "+p.Scheduler)
+	}
+	parts = append(parts,
+		fmt.Sprintf("Queue %d", p.NrRequests),
+		"Read-ahead "+formatSize(int64(p.ReadAheadKB)*1024),
+		"TRIM "+p.trimSummary())
+	if p.WriteCache != "" {
+		parts = append(parts, "Cache "+p.WriteCache)
+	}
+	if p.Firmware != "" {
+		parts = append(parts, "FW "+p.Firmware)
+	}
+	if p.WWN != "" {
+		parts = append(parts, "WWN "+p.WWN)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// trimSummary describes discard support as "granularity / max" or "no".
+func (p *DiskProps) trimSummary() string {
+	if p.DiscardMax == 0 {
+		return "no"
+	}
+	return formatSize(p.DiscardGranularity) + " / " + formatSize(p.DiscardMax)
+}
+
+func optionalSize(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return formatSize(n)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
 func printDiskSectionHeader(disk DiskInfo) {
 	sep := "━━"
 	if !useUnicode {
@@ -342,23 +448,74 @@ func printDiskSectionHeader(disk DiskInfo) {
 		formatSize(disk.SizeBytes), sep)
 	fmt.Println()
 	fmt.Printf("  %s%s%s\n", colorBold, label, colorReset)
+	if disk.FSType != "" {
+		fs := "Filesystem: " + disk.FSType
+		if len(disk.MountOptions) > 0 {
+			fs += " (" + strings.Join(disk.MountOptions, ", ") + ")"
+		}
+		fmt.Printf("  %s%s%s\n", colorDim, fs, colorReset)
+	}
+	if disk.Props != nil {
+		fmt.Printf("  %s%s%s\n", colorDim, disk.Props.summary(), colorReset)
+	}
+	if len(disk.IDs) > 0 {
+		fmt.Printf("  %sID: %s%s\n", colorDim, disk.IDs[0], colorReset)
+	}
 	fmt.Println()
+
+	if disk.Stack != nil {
+		top := disk.MountPoint
+		if top == "" {
+			top = disk.Device
+		}
+		fmt.Printf("  %sStorage stack:%s\n", colorBold, colorReset)
+		printTree([]treeNode{{Label: top, Children: []treeNode{disk.Stack.treeNode()}}}, "  ")
+		fmt.Println()
+	}
 }
 
-func printHealthReport(result HealthResult) {
-	// Status line.
-	var statusColor string
-	switch result.Status {
+// treeNode is one line of an indented tree printed by printTree.
+type treeNode struct {
+	Label    string
+	Children []treeNode
+}
+
+// printTree prints nodes as an indented tree using the box-drawing characters.
+func printTree(nodes []treeNode, indent string) {
+	for _, n := range nodes {
+		fmt.Printf("%s%s\n", indent, n.Label)
+		printTreeChildren(n.Children, indent)
+	}
+}
+
+func printTreeChildren(nodes []treeNode, indent string) {
+	for i, n := range nodes {
+		branch, next := boxML+boxH+" ", boxV+"  "
+		if i == len(nodes)-1 {
+			branch, next = boxBL+boxH+" ", "   "
+		}
+		fmt.Printf("%s%s%s\n", indent, branch, n.Label)
+		printTreeChildren(n.Children, indent+next)
+	}
+}
+
+// healthStatusColor returns the colour used for a HealthResult status.
+func healthStatusColor(status string) string {
+	switch status {
 	case "HEALTHY":
-		statusColor = colorGreen
+		return colorGreen
 	case "WARNING":
-		statusColor = colorYellow
+		return colorYellow
 	case "CRITICAL":
-		statusColor = colorRed
+		return colorRed
 	default:
-		statusColor = colorDim
+		return colorDim
 	}
-	fmt.Printf("  Health: %s%s%s\n", statusColor, result.Status, colorReset)
+}
+
+func printHealthReport(result HealthResult) {
+	// Status line.
+	fmt.Printf("  Health: %s%s%s\n", healthStatusColor(result.Status), result.Status, colorReset)
 
 	if result.Message != "" {
 		fmt.Printf("  %s%s%s\n", colorDim, result.Message, colorReset)
@@ -383,17 +540,82 @@ func printHealthReport(result HealthResult) {
 		}
 		printTable(headers, rows, aligns)
 	}
+
+	if k := result.KernelErrors; k != nil {
+		fmt.Println()
+		if k.Total == 0 {
+			fmt.Printf("  %sKernel errors: none logged for this device (%s)%s\n", colorDim, k.Source, colorReset)
+		} else {
+			fmt.Printf("  %sKernel errors:%s %s%d%s message(s) in %s\n",
+				colorBold, colorReset, colorYellow, k.Total, colorReset, k.Source)
+			categories := make([]string, 0, len(k.Counts))
+			for c := range k.Counts {
+				categories = append(categories, c)
+			}
+			sort.Strings(categories)
+			rows := make([][]string, len(categories))
+			for i, c := range categories {
+				rows[i] = []string{c, formatNumber(int64(k.Counts[c]))}
+			}
+			printTable([]string{"Category", "Count"}, rows, []byte{'l', 'r'})
+			fmt.Printf("  %sMost recent:%s\n", colorDim, colorReset)
+			for _, line := range k.Recent {
+				fmt.Printf("  %s%s%s\n", colorDim, line, colorReset)
+			}
+		}
+	}
+
+	if len(result.Members) > 0 {
+		fmt.Println()
+		fmt.Printf("  %sPhysical disks:%s\n", colorBold, colorReset)
+		headers := []string{"Slot", "Model", "Serial", "Temp", "Power On", "Realloc", "Media Err", "Health"}
+		aligns := []byte{'l', 'l', 'l', 'r', 'r', 'r', 'r', 'c'}
+		rows := make([][]string, len(result.Members))
+		for i, m := range result.Members {
+			h := m.Health
+			temp := "-"
+			if h.Temperature > 0 {
+				temp = fmt.Sprintf("%dC", h.Temperature)
+			}
+			rows[i] = []string{
+				m.Slot,
+				h.Model,
+				h.Serial,
+				temp,
+				formatNumber(int64(h.PowerOnHours)),
+				formatNumber(int64(h.ReallocatedSectors)),
+				formatNumber(int64(h.MediaErrors)),
+				healthStatusColor(h.Status) + h.Status + colorReset,
+			}
+		}
+		printTable(headers, rows, aligns)
+	}
 	fmt.Println()
 }
 
-func printSpeedReport(result SpeedResult, diskType string) {
-	if result.DirectIO {
-		fmt.Printf("  %sNote: using direct I/O (bypassing OS cache)%s\n", colorDim, colorReset)
-	} else {
-		fmt.Printf("  %sNote: using buffered I/O (direct I/O not available)%s\n", colorDim, colorReset)
+func printSpeedReport(result SpeedResult, disk DiskInfo) {
+	diskType := disk.DiskType
+	link := disk.Link
+	if link != nil && link.MBPS <= 0 {
+		link = nil
+	}
+	fmt.Printf("  %sNote: %s%s\n", colorDim, ioModeNote(result.IOMode, result.IOFallback), colorReset)
+	if result.IOMode == ioDirect {
+		fmt.Printf("  %sDirect I/O alignment: %s%s\n", colorDim, result.Align.describe(), colorReset)
+	}
+	params := fmt.Sprintf("Test size: %s | Block size: %s", formatSize(result.TestSize), formatSize(int64(result.BlockSize)))
+	if result.Streams > 1 || result.QueueDepth > 1 {
+		params += fmt.Sprintf(" | Streams: %d | Queue depth: %d per stream", result.Streams, result.QueueDepth)
+	}
+	fmt.Printf("  %s%s%s\n", colorDim, params, colorReset)
+	if link != nil {
+		fmt.Printf("  %sLink: %s, theoretical %s MB/s%s\n",
+			colorDim, link.describe(), formatFloat(link.MBPS, 0), colorReset)
+		if link.degraded() {
+			fmt.Printf("  %sWarning: link negotiated below the device maximum (%s MB/s possible) - check the slot, port or cable%s\n",
+				colorYellow, formatFloat(link.MaxMBPS, 0), colorReset)
+		}
 	}
-	fmt.Printf("  %sTest size: %s | Block size: %s%s\n",
-		colorDim, formatSize(result.TestSize), formatSize(int64(result.BlockSize)), colorReset)
 	fmt.Println()
 
 	readRating := rateSpeed(result.ReadMBPS, diskType)
@@ -413,11 +635,44 @@ func printSpeedReport(result SpeedResult, diskType string) {
 			ratingColor(writeRating) + writeRating + colorReset,
 		},
 	}
+	if link != nil {
+		headers = append(headers, "% of Link")
+		aligns = append(aligns, 'r')
+		rows[0] = append(rows[0], fmt.Sprintf("%.0f%%", result.ReadMBPS/link.MBPS*100))
+		rows[1] = append(rows[1], fmt.Sprintf("%.0f%%", result.WriteMBPS/link.MBPS*100))
+	}
 	printTable(headers, rows, aligns)
 	fmt.Println()
+
+	if result.Streams > 1 {
+		region := result.TestSize / int64(result.Streams)
+		headers = []string{"Stream", "Region", "Read (MB/s)", "Write (MB/s)"}
+		aligns = []byte{'r', 'l', 'r', 'r'}
+		rows = nil
+		for i := 0; i < result.Streams; i++ {
+			read, write := "-", "-"
+			if i < len(result.ReadStreamMBPS) {
+				read = formatFloat(result.ReadStreamMBPS[i], 1)
+			}
+			if i < len(result.WriteStreamMBPS) {
+				write = formatFloat(result.WriteStreamMBPS[i], 1)
+			}
+			from := "0"
+			if i > 0 {
+				from = formatSize(int64(i) * region)
+			}
+			rows = append(rows, []string{fmt.Sprintf("%d", i+1), from + " - " + formatSize(int64(i+1)*region), read, write})
+		}
+		printTable(headers, rows, aligns)
+		fmt.Println()
+	}
 }
 
 func printIOPSReport(results []IOPSResult, diskType string) {
+	fmt.Printf("  %sNote: %s%s\n", colorDim, ioModeNote(results[0].IOMode, results[0].IOFallback), colorReset)
+	if bs := results[0].BlockSize; bs != iopsBlockSize {
+		fmt.Printf("  %sNote: %s blocks instead of 4K, as direct I/O on this file requires%s\n", colorDim, formatSize(int64(bs)), colorReset)
+	}
 	fmt.Println()
 
 	headers := []string{"Test", "IOPS", "Latency (us)", "Rating"}
@@ -442,3 +697,80 @@ func printIOPSReport(results []IOPSResult, diskType string) {
 	printTable(headers, rows, aligns)
 	fmt.Println()
 }
+
+func printThermalReport(r *ThermalResult) {
+	if r == nil {
+		return
+	}
+	warn := "unknown"
+	if r.WarnTempC > 0 {
+		warn = fmt.Sprintf("%dC", r.WarnTempC)
+	}
+	peakColor := colorGreen
+	if r.WarnTempC > 0 && r.PeakC >= r.WarnTempC {
+		peakColor = colorRed
+	}
+	fmt.Printf("  Drive temperature: start %dC, peak %s%dC%s (warning threshold %s)\n",
+		r.StartC, peakColor, r.PeakC, colorReset, warn)
+	fmt.Printf("  %s%d samples via %s%s\n", colorDim, len(r.Samples), r.Source, colorReset)
+
+	// Per-phase summary.
+	var phases []string
+	peak := make(map[string]int)
+	for _, s := range r.Samples {
+		if s.Phase == "" {
+			continue
+		}
+		if _, ok := peak[s.Phase]; !ok {
+			phases = append(phases, s.Phase)
+		}
+		peak[s.Phase] = max(peak[s.Phase], s.TempC)
+	}
+	if len(phases) > 0 {
+		fmt.Println()
+		headers := []string{"Phase", "Peak Temp"}
+		aligns := []byte{'l', 'r'}
+		rows := make([][]string, len(phases))
+		for i, p := range phases {
+			rows[i] = []string{p, fmt.Sprintf("%dC", peak[p])}
+		}
+		printTable(headers, rows, aligns)
+	}
+
+	for _, t := range r.Throttled {
+		fmt.Printf("  %sThermal throttling suspected: %s%s\n", colorYellow, t, colorReset)
+	}
+	fmt.Println()
+}
+
+// printDeviceIOReport compares what each benchmark phase counted with what
+// the block device completed, to show when results came from the page cache.
+func printDeviceIOReport(r *DeviceIOResult) {
+	if r == nil {
+		return
+	}
+	fmt.Printf("  Device activity (%s, from /sys/block/%s/stat)\n", r.Device, r.Device)
+	headers := []string{"Phase", "Mode", "Benchmark", "Device Read", "Device Write", "Device I/Os", "Util", "Avg Queue"}
+	aligns := []byte{'l', 'l', 'r', 'r', 'r', 'r', 'r', 'r'}
+	var rows [][]string
+	var findings []string
+	for _, p := range r.Phases {
+		counted := formatSize(p.CountedBytes)
+		if share, low := deviceShortfall(p); low {
+			counted = colorYellow + counted + colorReset
+			where := "writes stayed in the page cache"
+			if strings.Contains(p.Phase, "Read") {
+				where = "reads were served from the page cache"
+			}
+			findings = append(findings, fmt.Sprintf("%s (%s): the device moved %.0f%% of the %s counted - %s",
+				p.Phase, p.Mode, share*100, formatSize(p.CountedBytes), where))
+		}
+		rows = append(rows, []string{p.Phase, p.Mode, counted, formatSize(p.ReadBytes), formatSize(p.WriteBytes),
+			formatNumber(p.ReadIOs + p.WriteIOs), fmt.Sprintf("%.0f%%", p.UtilPct), formatFloat(p.AvgQueue, 2)})
+	}
+	printTable(headers, rows, aligns)
+	for _, f := range findings {
+		fmt.Printf("  %sWarning: %s%s\n", colorYellow, f, colorReset)
+	}
+	fmt.Println()
+}
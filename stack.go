@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// resolveStackedDevice resolves a device-mapper or md device down to the
+// physical disks it is built on. Returns nil for plain disks and partitions.
+func resolveStackedDevice(device, mountPoint string) *DiskInfo {
+	stack := resolveStackPlatform(device)
+	if stack == nil || !stack.isVirtual() {
+		return nil
+	}
+
+	detected := detectDisks()
+	var members []DiskInfo
+	for _, leaf := range stack.leaves() {
+		m := DiskInfo{Device: leaf, Name: leaf, DiskType: guessDiskType(leaf), Interface: "Unknown"}
+		for _, d := range detected {
+			if d.Device == leaf {
+				m = d
+				break
+			}
+		}
+		m.MountPoint = ""
+		members = append(members, m)
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	// Rate the stack by its slowest member.
+	diskType := members[0].DiskType
+	for _, m := range members[1:] {
+		if diskTypeRank(m.DiskType) < diskTypeRank(diskType) {
+			diskType = m.DiskType
+		}
+	}
+
+	name := stack.Name
+	if name == "" {
+		name = strings.TrimPrefix(stack.Device, "/dev/")
+	}
+	size := stack.SizeBytes
+	if mountPoint != "" {
+		size = getPartitionSize(mountPoint)
+	}
+	return &DiskInfo{
+		Device: device, Name: name, DiskType: diskType, Interface: stack.describe(),
+		SizeBytes: size, MountPoint: mountPoint, Stack: stack, Members: members,
+	}
+}
+
+// checkStackHealth checks every physical disk under a stacked device and
+// the state of any md arrays in between. The worst result wins.
+func checkStackHealth(disk DiskInfo) HealthResult {
+	result := HealthResult{
+		Status:  "UNKNOWN",
+		Message: fmt.Sprintf("%s on %d physical disk(s)", disk.Interface, len(disk.Members)),
+	}
+	for _, a := range disk.Stack.mdArrays() {
+		attr := HealthAttr{Name: "Array " + a.Name, Value: a.summary(), Status: "OK"}
+		switch {
+		case a.State == "inactive" || a.Active == 0:
+			attr.Status = "FAIL"
+			result.Status = worseStatus(result.Status, "CRITICAL")
+		case a.degraded():
+			attr.Status = "WARN"
+			result.Status = worseStatus(result.Status, "WARNING")
+		}
+		result.Attributes = append(result.Attributes, attr)
+	}
+	for _, m := range disk.Members {
+		h := checkHealth(m)
+		result.Members = append(result.Members, MemberHealth{Slot: m.Device, Health: h})
+		result.Status = worseStatus(result.Status, h.Status)
+	}
+	return result
+}
+
+// diskTypeRank orders disk types from slowest to fastest.
+func diskTypeRank(t string) int {
+	switch t {
+	case "hdd":
+		return 0
+	case "usb":
+		return 1
+	case "ssd":
+		return 2
+	case "nvme":
+		return 3
+	}
+	return 2
+}
+
+// isVirtual reports whether the stack has any dm or md layer.
+func (n *StackNode) isVirtual() bool {
+	return n.Kind != "disk" && n.Kind != "partition"
+}
+
+// leaves returns the whole-disk devices at the bottom of the stack.
+func (n *StackNode) leaves() []string {
+	var out []string
+	seen := make(map[string]bool)
+	var walk func(s *StackNode)
+	walk = func(s *StackNode) {
+		if s.Kind == "disk" && len(s.Children) == 0 && !seen[s.Device] {
+			seen[s.Device] = true
+			out = append(out, s.Device)
+		}
+		for i := range s.Children {
+			walk(&s.Children[i])
+		}
+	}
+	walk(n)
+	return out
+}
+
+// describe summarises the virtual layers, top first, e.g. "dm-crypt/LVM/md raid1".
+func (n *StackNode) describe() string {
+	var kinds []string
+	for s := n; s != nil; {
+		switch s.Kind {
+		case "disk", "partition":
+		case "md":
+			if s.Array != nil && s.Array.Level != "" {
+				kinds = append(kinds, "md "+s.Array.Level)
+			} else {
+				kinds = append(kinds, "md")
+			}
+		default:
+			kinds = append(kinds, s.Kind)
+		}
+		if len(s.Children) == 0 {
+			break
+		}
+		s = &s.Children[0]
+	}
+	return strings.Join(kinds, "/")
+}
+
+// mdArrays returns every md array in the stack.
+func (n *StackNode) mdArrays() []*MDArray {
+	var out []*MDArray
+	if n.Array != nil {
+		out = append(out, n.Array)
+	}
+	for i := range n.Children {
+		out = append(out, n.Children[i].mdArrays()...)
+	}
+	return out
+}
+
+// label formats a node for the stack tree.
+func (n *StackNode) label() string {
+	parts := []string{strings.TrimPrefix(n.Device, "/dev/"), n.Kind}
+	if n.Name != "" {
+		parts = append(parts, n.Name)
+	}
+	if n.SizeBytes > 0 {
+		parts = append(parts, formatSize(n.SizeBytes))
+	}
+	if a := n.Array; a != nil {
+		if a.Level != "" {
+			parts = append(parts, a.Level)
+		}
+		if a.Status != "" {
+			parts = append(parts, fmt.Sprintf("[%d/%d] [%s]", a.Devices, a.Active, a.Status))
+		}
+		if s := a.summary(); s != "clean" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// treeNode converts the stack into a printable tree.
+func (n *StackNode) treeNode() treeNode {
+	t := treeNode{Label: n.label()}
+	for i := range n.Children {
+		t.Children = append(t.Children, n.Children[i].treeNode())
+	}
+	return t
+}
+
+// degraded reports whether the array is missing members.
+func (a *MDArray) degraded() bool {
+	return a.Active < a.Devices || len(a.Failed) > 0
+}
+
+// summary describes the array state in a few words.
+func (a *MDArray) summary() string {
+	var s []string
+	if a.State != "active" {
+		s = append(s, a.State)
+	}
+	if a.degraded() {
+		s = append(s, "degraded")
+	}
+	if len(a.Failed) > 0 {
+		s = append(s, "failed: "+strings.Join(a.Failed, ","))
+	}
+	if a.Action != "" {
+		verb := a.Action
+		if a.Action == "recovery" {
+			verb = "rebuilding"
+		}
+		if a.Progress >= 0 {
+			verb += fmt.Sprintf(" %.1f%%", a.Progress)
+		}
+		s = append(s, verb)
+	}
+	if len(s) == 0 {
+		return "clean"
+	}
+	return strings.Join(s, ", ")
+}
+
+var (
+	mdstatHeadRe     = regexp.MustCompile(`^(md\S*)\s*:\s*(.*)$`)
+	mdstatMembersRe  = regexp.MustCompile(`\[(\d+)/(\d+)\]\s*\[([U_]+)\]`)
+	mdstatProgressRe = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*([\d.]+)%`)
+	mdstatPendingRe  = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*(DELAYED|PENDING)`)
+)
+
+// parseMdstat parses /proc/mdstat into arrays keyed by name (md0).
+func parseMdstat(text string) map[string]*MDArray {
+	arrays := make(map[string]*MDArray)
+	var cur *MDArray
+	for _, line := range strings.Split(text, "\n") {
+		if m := mdstatHeadRe.FindStringSubmatch(line); m != nil {
+			cur = &MDArray{Name: m[1], Progress: -1}
+			arrays[cur.Name] = cur
+			for i, f := range strings.Fields(m[2]) {
+				switch {
+				case i == 0:
+					cur.State = f
+				case strings.HasPrefix(f, "("):
+					// (read-only), (auto-read-only)
+				case strings.HasPrefix(f, "raid") || f == "linear" || f == "multipath":
+					cur.Level = f
+				default:
+					dev := f
+					if j := strings.Index(dev, "["); j >= 0 {
+						dev = dev[:j]
+					}
+					if strings.HasSuffix(f, "(F)") {
+						cur.Failed = append(cur.Failed, dev)
+					}
+					if !strings.HasSuffix(f, "(S)") && !strings.HasSuffix(f, "(F)") && cur.Devices == 0 {
+						cur.Active++ // provisional until the [n/m] line
+					}
+				}
+			}
+			continue
+		}
+		if cur == nil || strings.TrimSpace(line) == "" {
+			cur = nil
+			continue
+		}
+		if m := mdstatMembersRe.FindStringSubmatch(line); m != nil {
+			cur.Devices, _ = strconv.Atoi(m[1])
+			cur.Active, _ = strconv.Atoi(m[2])
+			cur.Status = m[3]
+		}
+		if m := mdstatProgressRe.FindStringSubmatch(line); m != nil {
+			cur.Action = m[1]
+			cur.Progress, _ = strconv.ParseFloat(m[2], 64)
+		} else if m := mdstatPendingRe.FindStringSubmatch(line); m != nil {
+			cur.Action = m[1] + " " + strings.ToLower(m[2])
+		}
+	}
+	for _, a := range arrays {
+		if a.Devices == 0 {
+			a.Devices = a.Active // linear / inactive arrays have no [n/m]
+		}
+	}
+	return arrays
+}
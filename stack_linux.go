@@ -0,0 +1,78 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resolveStackPlatform walks /sys/class/block/*/slaves from the device down
+// to the physical disks. Returns nil if the device is not a block device.
+func resolveStackPlatform(device string) *StackNode {
+	real, err := filepath.EvalSymlinks(device) // /dev/mapper/vg-lv -> /dev/dm-0
+	if err != nil {
+		real = device
+	}
+	name := filepath.Base(real)
+	if _, err := os.Stat(filepath.Join("/sys/class/block", name)); err != nil {
+		return nil
+	}
+	data, _ := os.ReadFile("/proc/mdstat")
+	node := buildStackNode(name, parseMdstat(string(data)), 0)
+	return &node
+}
+
+func buildStackNode(name string, mdstat map[string]*MDArray, depth int) StackNode {
+	sys := filepath.Join("/sys/class/block", name)
+	node := StackNode{Device: "/dev/" + name, Kind: "disk"}
+	if sectors, err := strconv.ParseInt(readSysfsFile(filepath.Join(sys, "size")), 10, 64); err == nil {
+		node.SizeBytes = sectors * 512
+	}
+
+	switch {
+	case fileExists(filepath.Join(sys, "partition")):
+		node.Kind = "partition"
+		if parent := sysfsDiskName(name); parent != name && depth < 16 {
+			node.Children = []StackNode{buildStackNode(parent, mdstat, depth+1)}
+		}
+		return node
+	case strings.HasPrefix(name, "dm-"):
+		node.Name = readSysfsFile(filepath.Join(sys, "dm", "name"))
+		node.Kind = dmKind(readSysfsFile(filepath.Join(sys, "dm", "uuid")))
+	case strings.HasPrefix(name, "md"):
+		node.Kind = "md"
+		node.Array = mdstat[name]
+	}
+
+	if depth >= 16 {
+		return node // guard against sysfs loops
+	}
+	slaves, _ := os.ReadDir(filepath.Join(sys, "slaves"))
+	for _, s := range slaves {
+		node.Children = append(node.Children, buildStackNode(s.Name(), mdstat, depth+1))
+	}
+	return node
+}
+
+// dmKind classifies a device-mapper target by its uuid prefix.
+func dmKind(uuid string) string {
+	switch {
+	case strings.HasPrefix(uuid, "CRYPT-"):
+		return "dm-crypt"
+	case strings.HasPrefix(uuid, "LVM-"):
+		return "LVM"
+	case strings.HasPrefix(uuid, "mpath-"):
+		return "multipath"
+	case strings.HasPrefix(uuid, "part"):
+		return "partition"
+	}
+	return "dm"
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
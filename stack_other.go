@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func resolveStackPlatform(device string) *StackNode {
+	return nil // dm/md stacks are Linux-only
+}
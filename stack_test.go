@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const mdstatSample = `Personalities : [raid1] [raid6] [raid5] [raid4] [raid10] [linear] [multipath] [raid0]
+md0 : active raid1 sdb1[1] sda1[0]
+      976630464 blocks super 1.2 [2/2] [UU]
+      bitmap: 1/8 pages [4KB], 65536KB chunk
+
+md1 : active raid5 sdf1[4] sde1[2] sdd1[1] sdc1[0] sdg1[5](S)
+      5860147200 blocks super 1.2 level 5, 512k chunk, algorithm 2 [4/4] [UUUU]
+      bitmap: 0/15 pages [0KB], 65536KB chunk
+
+md2 : active raid1 sdi1[1](F) sdh1[0]
+      1953382464 blocks super 1.2 [2/1] [U_]
+      bitmap: 3/15 pages [12KB], 65536KB chunk
+
+md3 : active raid1 sdk1[2] sdj1[0]
+      1953382464 blocks super 1.2 [2/1] [U_]
+      [=>...................]  recovery =  8.5% (166185472/1953382464) finish=148.5min speed=200558K/sec
+      bitmap: 15/15 pages [60KB], 65536KB chunk
+
+md4 : active raid10 sdn1[3] sdm1[2] sdl1[1] sdo1[0]
+      1953260544 blocks super 1.2 512K chunks 2 near-copies [4/4] [UUUU]
+      [>....................]  resync =  0.4% (8388608/1953260544) finish=196.2min speed=165157K/sec
+
+md5 : active (auto-read-only) raid1 sdq1[1] sdp1[0]
+      488254464 blocks super 1.2 [2/2] [UU]
+      	resync=PENDING
+
+md127 : inactive sdr[0](S)
+      976762584 blocks super 1.2
+
+unused devices: <none>
+`
+
+func TestParseMdstat(t *testing.T) {
+	arrays := parseMdstat(mdstatSample)
+	tests := []struct {
+		want    MDArray
+		summary string
+	}{
+		{MDArray{Name: "md0", State: "active", Level: "raid1", Devices: 2, Active: 2, Status: "UU", Progress: -1}, "clean"},
+		{MDArray{Name: "md1", State: "active", Level: "raid5", Devices: 4, Active: 4, Status: "UUUU", Progress: -1}, "clean"},
+		{MDArray{Name: "md2", State: "active", Level: "raid1", Devices: 2, Active: 1, Status: "U_", Failed: []string{"sdi1"}, Progress: -1},
+			"degraded, failed: sdi1"},
+		{MDArray{Name: "md3", State: "active", Level: "raid1", Devices: 2, Active: 1, Status: "U_", Action: "recovery", Progress: 8.5},
+			"degraded, rebuilding 8.5%"},
+		{MDArray{Name: "md4", State: "active", Level: "raid10", Devices: 4, Active: 4, Status: "UUUU", Action: "resync", Progress: 0.4},
+			"resync 0.4%"},
+		{MDArray{Name: "md5", State: "active", Level: "raid1", Devices: 2, Active: 2, Status: "UU", Action: "resync pending", Progress: -1},
+			"resync pending"},
+		{MDArray{Name: "md127", State: "inactive", Progress: -1}, "inactive"},
+	}
+	if len(arrays) != len(tests) {
+		t.Errorf("parsed %d arrays, want %d", len(arrays), len(tests))
+	}
+	for _, tt := range tests {
+		got, ok := arrays[tt.want.Name]
+		if !ok {
+			t.Errorf("%s not parsed", tt.want.Name)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.want.Name, *got, tt.want)
+		}
+		if s := got.summary(); s != tt.summary {
+			t.Errorf("%s: summary %q, want %q", tt.want.Name, s, tt.summary)
+		}
+	}
+}
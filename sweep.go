@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sweepSizes are the transfer sizes of the block-size sweep, 512 B to 64 MB
+// in powers of two as in ATTO Disk Benchmark.
+var sweepSizes = func() []int64 {
+	var sizes []int64
+	for s := int64(512); s <= 64*1024*1024; s *= 2 {
+		sizes = append(sizes, s)
+	}
+	return sizes
+}()
+
+// sweepMeasure is how long each transfer size is written and then read.
+const sweepMeasure = 2 // seconds
+
+// sweepSaturation is the share of peak throughput at which the device
+// counts as saturated.
+const sweepSaturation = 0.9
+
+// sweepPoint is the sequential throughput at one transfer size.
+type sweepPoint struct {
+	Size      int64
+	WriteMBPS float64
+	ReadMBPS  float64
+	WriteIOPS float64
+	ReadIOPS  float64
+	Errors    int64
+}
+
+// runSweep measures sequential write and read throughput at every
+// transfer size and prints a table and a bar chart.
+func runSweep(disk DiskInfo, testDir string, size int64, mon benchObserver) {
+	testFile := filepath.Join(testDir, ".diskbench_sweep")
+	registerCleanup(testFile)
+	defer func() {
+		os.Remove(testFile)
+		unregisterCleanup(testFile)
+	}()
+
+	size = speedTestSize(disk, testDir, size)
+	fmt.Fprintf(progressOut, "  Preparing sweep test file (%s)...", formatSize(size))
+	if err := createTestFile(testFile, size); err != nil {
+		fmt.Fprintf(progressOut, " error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(progressOut, " done.\n")
+
+	var points []sweepPoint
+	var mode achievedMode
+	align := benchAlignment(testFile)
+	skipped := 0
+	for _, bs := range sweepSizes {
+		if bs > size {
+			break
+		}
+		if bs < int64(align.Offset) {
+			skipped++
+			continue
+		}
+		w := workload{
+			Name: "Sweep " + formatSize(bs), Pattern: "sequential",
+			BlockSizes: []string{strconv.FormatInt(bs, 10)}, QueueDepth: 1, Duration: sweepMeasure,
+		}
+		p := sweepPoint{Size: bs}
+		wr := runWorkload(w, testFile, size, sweepMeasure, mon)
+		dropCaches() // read back from the disk, not the writes still in the page cache
+		w.ReadPct = 100
+		rd := runWorkload(w, testFile, size, sweepMeasure, mon)
+		mode.note(wr.IOMode, wr.IOFallback)
+		mode.note(rd.IOMode, rd.IOFallback)
+		p.WriteMBPS, p.WriteIOPS = wr.MBPS, wr.IOPS
+		p.ReadMBPS, p.ReadIOPS = rd.MBPS, rd.IOPS
+		p.Errors = wr.Errors + rd.Errors
+		fmt.Fprintf(progressOut, "  %8s  write %10s MB/s  read %10s MB/s\n", formatSize(bs),
+			formatFloat(p.WriteMBPS, 1), formatFloat(p.ReadMBPS, 1))
+		points = append(points, p)
+	}
+	fmt.Println()
+	fmt.Printf("  %sNote: %s%s\n", colorDim, ioModeNote(mode.get()), colorReset)
+	if skipped > 0 {
+		fmt.Printf("  %sNote: transfers below %s skipped, direct I/O on this file needs %s alignment%s\n",
+			colorDim, formatSize(int64(align.Offset)), formatSize(int64(align.Offset)), colorReset)
+	}
+	printSweepReport(points)
+}
+
+// saturationSize returns the smallest transfer size reaching
+// sweepSaturation of the peak, and the peak.
+func saturationSize(points []sweepPoint, get func(sweepPoint) float64) (int64, float64) {
+	peak := 0.0
+	for _, p := range points {
+		peak = max(peak, get(p))
+	}
+	for _, p := range points {
+		if peak > 0 && get(p) >= peak*sweepSaturation {
+			return p.Size, peak
+		}
+	}
+	return 0, peak
+}
+
+// chartBar draws a horizontal bar of fraction*width cells padded to width,
+// with half cells when Unicode is available.
+func chartBar(fraction float64, width int) string {
+	fraction = min(max(fraction, 0), 1)
+	if !useUnicode {
+		n := int(fraction*float64(width) + 0.5)
+		return strings.Repeat("#", n) + strings.Repeat(" ", width-n)
+	}
+	halves := int(fraction*float64(width)*2 + 0.5)
+	return strings.Repeat("█", halves/2) + strings.Repeat("▌", halves%2) +
+		strings.Repeat(" ", width-(halves+1)/2)
+}
+
+// printSweepReport prints the sweep as a table, a bar chart and the
+// transfer size at which each direction saturates.
+func printSweepReport(points []sweepPoint) {
+	if len(points) == 0 {
+		return
+	}
+	headers := []string{"Transfer Size", "Write (MB/s)", "Read (MB/s)", "Write IOPS", "Read IOPS"}
+	aligns := []byte{'r', 'r', 'r', 'r', 'r'}
+	rows := make([][]string, len(points))
+	for i, p := range points {
+		w, r := formatFloat(p.WriteMBPS, 1), formatFloat(p.ReadMBPS, 1)
+		if p.Errors > 0 {
+			w, r = colorRed+"error"+colorReset, colorRed+"error"+colorReset
+		}
+		rows[i] = []string{formatSize(p.Size), w, r, formatFloat(p.WriteIOPS, 0), formatFloat(p.ReadIOPS, 0)}
+	}
+	printTable(headers, rows, aligns)
+	fmt.Println()
+
+	peak := 0.0
+	for _, p := range points {
+		peak = max(peak, p.WriteMBPS, p.ReadMBPS)
+	}
+	if peak <= 0 {
+		return
+	}
+	const width = 40
+	for _, p := range points {
+		fmt.Printf("  %8s %sW%s %s %10s\n", formatSize(p.Size), colorYellow, colorReset,
+			chartBar(p.WriteMBPS/peak, width), formatFloat(p.WriteMBPS, 1))
+		fmt.Printf("  %8s %sR%s %s %10s\n", "", colorCyan, colorReset,
+			chartBar(p.ReadMBPS/peak, width), formatFloat(p.ReadMBPS, 1))
+	}
+	fmt.Printf("  %s%8s   MB/s, bars scaled to the %s MB/s peak%s\n", colorDim, "", formatFloat(peak, 1), colorReset)
+	fmt.Println()
+
+	for _, dir := range []struct {
+		name string
+		get  func(sweepPoint) float64
+	}{
+		{"Write", func(p sweepPoint) float64 { return p.WriteMBPS }},
+		{"Read", func(p sweepPoint) float64 { return p.ReadMBPS }},
+	} {
+		size, top := saturationSize(points, dir.get)
+		if size == 0 {
+			continue
+		}
+		small := dir.get(points[0]) / top * 100
+		fmt.Printf("  %s saturates at %s (%.0f%% of the %s MB/s peak); %s transfers reach %.0f%%.\n",
+			dir.name, formatSize(size), sweepSaturation*100, formatFloat(top, 1), formatSize(points[0].Size), small)
+	}
+	fmt.Println()
+}
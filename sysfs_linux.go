@@ -0,0 +1,276 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// detectDisksSysfs enumerates whole disks from <root>/sys/block without
+// lsblk. Mount points come from <root>/proc/self/mountinfo and serials from
+// the udev database, the same sources lsblk uses. root is "/" except when
+// reading a captured tree.
+func detectDisksSysfs(root string) []DiskInfo {
+	entries, err := os.ReadDir(filepath.Join(root, "sys/block"))
+	if err != nil {
+		return nil
+	}
+	mountinfo, _ := os.ReadFile(filepath.Join(root, "proc/self/mountinfo"))
+	mounts := parseMountinfo(string(mountinfo))
+
+	var disks []DiskInfo
+	for _, e := range entries {
+		name := e.Name()
+		sys := filepath.Join(root, "sys/block", name)
+
+		// loop, ram, dm-* and md* have no backing device; optical drives
+		// are SCSI type 5. lsblk reports neither as TYPE "disk". zram has
+		// no device either but lsblk lists it.
+		if _, err := os.Stat(filepath.Join(sys, "device")); err != nil && !strings.HasPrefix(name, "zram") {
+			continue
+		}
+		if readSysfsFile(filepath.Join(sys, "device/type")) == "5" {
+			continue
+		}
+
+		sectors, _ := strconv.ParseInt(readSysfsFile(filepath.Join(sys, "size")), 10, 64)
+		rota := readSysfsFile(filepath.Join(sys, "queue/rotational")) != "0"
+		tran := sysfsTransport(sys)
+		if tran == "" && readSysfsFile(filepath.Join(sys, "removable")) == "1" && strings.HasPrefix(name, "sd") {
+			tran = "usb" // removable SCSI disks are USB sticks and card readers
+		}
+		diskType, iface := classifyLinuxDisk(root, name, tran, rota)
+
+		model := readSysfsFile(filepath.Join(sys, "device/model"))
+		if vendor := readSysfsFile(filepath.Join(sys, "device/vendor")); model == "" && !strings.HasPrefix(vendor, "0x") {
+			model = vendor // virtio reports a PCI vendor id here, not a name
+		}
+		if model == "" {
+			model = "Unknown"
+		}
+
+		disks = append(disks, DiskInfo{
+			Device:     "/dev/" + name,
+			Name:       model,
+			DiskType:   diskType,
+			Interface:  iface,
+			SizeBytes:  sectors * 512,
+			MountPoint: sysfsMountPoint(sys, mounts),
+			Serial:     sysfsSerial(root, sys),
+		})
+	}
+	return disks
+}
+
+// sysfsTransport guesses the lsblk TRAN value from the device's position
+// in the sysfs device tree.
+func sysfsTransport(sys string) string {
+	real, err := filepath.EvalSymlinks(sys)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case strings.Contains(real, "/nvme/"):
+		return "nvme"
+	case strings.Contains(real, "/usb"):
+		return "usb"
+	case strings.Contains(real, "/ata"):
+		return "sata"
+	}
+	return ""
+}
+
+// sysfsSerial reads the serial from the udev database, falling back to the
+// device's own serial attribute (NVMe and some SCSI drivers).
+func sysfsSerial(root, sys string) string {
+	if dev := readSysfsFile(filepath.Join(sys, "dev")); dev != "" {
+		data, _ := os.ReadFile(filepath.Join(root, "run/udev/data", "b"+dev))
+		for _, line := range strings.Split(string(data), "\n") {
+			if v, ok := strings.CutPrefix(line, "E:ID_SERIAL_SHORT="); ok {
+				return strings.TrimSpace(v)
+			}
+		}
+	}
+	return readSysfsFile(filepath.Join(sys, "device/serial"))
+}
+
+// sysfsMountPoint returns where the disk, or else its first mounted
+// partition or device-mapper/md holder (LVM, LUKS, RAID on the disk or a
+// partition), is mounted, matching by major:minor.
+func sysfsMountPoint(sys string, mounts []mountEntry) string {
+	devs := []string{readSysfsFile(filepath.Join(sys, "dev"))}
+	parts, _ := filepath.Glob(filepath.Join(sys, "*", "partition"))
+	sort.Strings(parts)
+	for _, p := range parts {
+		devs = append(devs, readSysfsFile(filepath.Join(filepath.Dir(p), "dev")))
+	}
+	holders, _ := filepath.Glob(filepath.Join(sys, "holders", "*"))
+	partHolders, _ := filepath.Glob(filepath.Join(sys, "*", "holders", "*"))
+	holders = append(holders, partHolders...)
+	sort.Strings(holders)
+	for _, h := range holders {
+		devs = append(devs, readSysfsFile(filepath.Join(h, "dev")))
+	}
+	for _, dev := range devs {
+		for _, m := range mounts {
+			if dev != "" && dev == strconv.FormatUint(uint64(m.Major), 10)+":"+strconv.FormatUint(uint64(m.Minor), 10) {
+				return m.MountPoint
+			}
+		}
+	}
+	return ""
+}
+
+// diskPropsPlatform reads queue and device properties for a disk or
+// partition (partitions report their parent disk's queue).
+func diskPropsPlatform(device string) *DiskProps {
+	if !strings.HasPrefix(device, "/dev/") {
+		return nil
+	}
+	real, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		real = device
+	}
+	return readDiskProps("/", sysfsDiskName(real))
+}
+
+// readDiskProps reads <root>/sys/block/<name>/queue and device attributes.
+func readDiskProps(root, name string) *DiskProps {
+	sys := filepath.Join(root, "sys/block", name)
+	queue := filepath.Join(sys, "queue")
+	if _, err := os.Stat(queue); err != nil {
+		return nil
+	}
+	atoi := func(path string) int {
+		n, _ := strconv.Atoi(readSysfsFile(path))
+		return n
+	}
+	atoi64 := func(path string) int64 {
+		n, _ := strconv.ParseInt(readSysfsFile(path), 10, 64)
+		return n
+	}
+
+	p := &DiskProps{
+		LogicalBlock:       atoi(filepath.Join(queue, "logical_block_size")),
+		PhysicalBlock:      atoi(filepath.Join(queue, "physical_block_size")),
+		OptimalIO:          atoi(filepath.Join(queue, "optimal_io_size")),
+		NrRequests:         atoi(filepath.Join(queue, "nr_requests")),
+		ReadAheadKB:        atoi(filepath.Join(queue, "read_ahead_kb")),
+		Rotational:         readSysfsFile(filepath.Join(queue, "rotational")) == "1",
+		DiscardGranularity: atoi64(filepath.Join(queue, "discard_granularity")),
+		DiscardMax:         atoi64(filepath.Join(queue, "discard_max_bytes")),
+		WriteCache:         readSysfsFile(filepath.Join(queue, "write_cache")),
+	}
+
+	// "mq-deadline kyber [none]" -> "none"
+	sched := readSysfsFile(filepath.Join(queue, "scheduler"))
+	if i := strings.Index(sched, "["); i >= 0 {
+		if j := strings.Index(sched[i:], "]"); j > 0 {
+			sched = sched[i+1 : i+j]
+		}
+	}
+	p.Scheduler = sched
+
+	// SCSI/ATA report firmware as "rev", NVMe as "firmware_rev".
+	p.Firmware = readSysfsFile(filepath.Join(sys, "device/firmware_rev"))
+	if p.Firmware == "" {
+		p.Firmware = readSysfsFile(filepath.Join(sys, "device/rev"))
+	}
+	// SCSI/ATA: device/wwid ("naa.5000..."); NVMe namespaces: wwid ("eui....").
+	p.WWN = readSysfsFile(filepath.Join(sys, "device/wwid"))
+	if p.WWN == "" {
+		p.WWN = readSysfsFile(filepath.Join(sys, "wwid"))
+	}
+	return p
+}
+
+// linkInfoPlatform reads the negotiated and maximum link of the bus a disk
+// is attached to: PCIe for NVMe, the libata link for SATA, or the USB
+// device node.
+func linkInfoPlatform(device string) *LinkInfo {
+	if !strings.HasPrefix(device, "/dev/") {
+		return nil
+	}
+	real, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		real = device
+	}
+	sys, err := filepath.EvalSymlinks(filepath.Join("/sys/block", sysfsDiskName(real)))
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(sys, "/usb"):
+		return usbLink(sys)
+	case strings.Contains(sys, "/nvme/"):
+		return pcieLink(filepath.Join(sys, "device", "device"))
+	}
+	for _, part := range strings.Split(sys, "/") {
+		if n, ok := strings.CutPrefix(part, "ata"); ok {
+			if _, err := strconv.Atoi(n); err == nil {
+				return sataLink(filepath.Join("/sys/class/ata_link", "link"+n))
+			}
+		}
+	}
+	return nil
+}
+
+func pcieLink(dir string) *LinkInfo {
+	speed := readSysfsFile(filepath.Join(dir, "current_link_speed"))
+	gts := leadingFloat(speed)
+	if gts == 0 {
+		return nil
+	}
+	maxSpeed := readSysfsFile(filepath.Join(dir, "max_link_speed"))
+	width, _ := strconv.Atoi(readSysfsFile(filepath.Join(dir, "current_link_width")))
+	maxWidth, _ := strconv.Atoi(readSysfsFile(filepath.Join(dir, "max_link_width")))
+	l := &LinkInfo{
+		Bus: "PCIe", Speed: fmt.Sprintf("%g GT/s", gts), Width: width, MaxWidth: maxWidth,
+		MBPS: pcieLaneMBPS(gts) * float64(max(width, 1)),
+	}
+	if maxGTS := leadingFloat(maxSpeed); maxGTS > 0 {
+		l.MaxSpeed = fmt.Sprintf("%g GT/s", maxGTS)
+		l.MaxMBPS = pcieLaneMBPS(maxGTS) * float64(max(maxWidth, 1))
+	}
+	return l
+}
+
+func sataLink(dir string) *LinkInfo {
+	speed := readSysfsFile(filepath.Join(dir, "sata_spd"))
+	gbps := leadingFloat(speed)
+	if gbps == 0 {
+		return nil // link down or not reported
+	}
+	// No maximum: hw_sata_spd_limit is the host port's limit, not the
+	// drive's, and a 3 Gb/s drive on a 6 Gb/s port is not degraded.
+	return &LinkInfo{Bus: "SATA", Speed: fmt.Sprintf("%g Gbps", gbps), MBPS: sataMBPS(gbps)}
+}
+
+// usbLink walks up from the block device to the USB device node, the
+// first ancestor with both "speed" and "version".
+func usbLink(sys string) *LinkInfo {
+	for dir := filepath.Dir(sys); strings.HasPrefix(dir, "/sys/devices/"); dir = filepath.Dir(dir) {
+		speed := readSysfsFile(filepath.Join(dir, "speed"))
+		version := readSysfsFile(filepath.Join(dir, "version"))
+		if speed == "" || version == "" {
+			continue
+		}
+		mbps := leadingFloat(speed)
+		if mbps == 0 {
+			return nil
+		}
+		l := &LinkInfo{Bus: "USB", Speed: fmt.Sprintf("%g Mbps", mbps), MBPS: usbMBPS(mbps)}
+		if maxMbps := usbVersionMbps(version); maxMbps > 0 {
+			l.MaxSpeed = fmt.Sprintf("%g Mbps", maxMbps)
+			l.MaxMBPS = usbMBPS(maxMbps)
+		}
+		return l
+	}
+	return nil
+}
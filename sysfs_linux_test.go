@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeSysfs builds a sysfs, mountinfo and udev tree under a temp root with:
+//
+//	sda      SATA SSD; sda1 unmounted, sda2 an LVM PV whose dm-0 holds /
+//	nvme0n1  NVMe; nvme0n1p1 mounted on /data
+//	sdb      USB disk, found by its place in the device tree
+//	sdc      removable SCSI disk with no transport in its path (card reader)
+//	sr0      optical drive, dm-0 and loop0: not disks
+func fakeSysfs(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	file := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(target, path string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// disk creates a block device at dev (a /sys/devices path) with its SCSI
+	// or NVMe device one level up the tree, and its /sys/block link.
+	disk := func(dev, name, majMin, sectors, rota, removable string) string {
+		blk := filepath.Join(dev, "block", name)
+		file(filepath.Join(blk, "dev"), majMin)
+		file(filepath.Join(blk, "size"), sectors)
+		file(filepath.Join(blk, "removable"), removable)
+		file(filepath.Join(blk, "queue/rotational"), rota)
+		link("../../../"+filepath.Base(dev), filepath.Join(blk, "device"))
+		rel, _ := filepath.Rel("sys/block", blk)
+		link(rel, filepath.Join("sys/block", name))
+		return blk
+	}
+
+	// SATA SSD with two partitions, the second an LVM physical volume.
+	ata := "sys/devices/pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0"
+	file(filepath.Join(ata, "type"), "0")
+	file(filepath.Join(ata, "vendor"), "ATA     ")
+	file(filepath.Join(ata, "model"), "Samsung SSD 870 ")
+	sda := disk(ata, "sda", "8:0", "976773168", "0", "0")
+	file(filepath.Join(sda, "sda1/partition"), "1")
+	file(filepath.Join(sda, "sda1/dev"), "8:1")
+	file(filepath.Join(sda, "sda2/partition"), "2")
+	file(filepath.Join(sda, "sda2/dev"), "8:2")
+	file("run/udev/data/b8:0", "S:disk/by-id/ata-Samsung_SSD_870_S6PNNX0T512345\nE:ID_SERIAL_SHORT=S6PNNX0T512345")
+
+	// LVM logical volume on sda2: holders on the partition, slaves on dm-0.
+	dm := "sys/devices/virtual/block/dm-0"
+	file(filepath.Join(dm, "dev"), "253:0")
+	file(filepath.Join(dm, "size"), "976771072")
+	link("../devices/virtual/block/dm-0", "sys/block/dm-0")
+	link("../../../../../../../../../../virtual/block/dm-0", filepath.Join(sda, "sda2/holders/dm-0"))
+	link("../../../../pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0/block/sda/sda2", filepath.Join(dm, "slaves/sda2"))
+
+	// NVMe namespace, its controller holding model and serial.
+	ctrl := "sys/devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0"
+	file(filepath.Join(ctrl, "model"), "WD_BLACK SN850X 2000GB")
+	file(filepath.Join(ctrl, "serial"), "23154Y800123")
+	nvme := filepath.Join(ctrl, "nvme0n1")
+	file(filepath.Join(nvme, "dev"), "259:0")
+	file(filepath.Join(nvme, "size"), "3907029168")
+	file(filepath.Join(nvme, "removable"), "0")
+	file(filepath.Join(nvme, "queue/rotational"), "0")
+	link("../../nvme0", filepath.Join(nvme, "device"))
+	link("../devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0/nvme0n1", "sys/block/nvme0n1")
+	file(filepath.Join(nvme, "nvme0n1p1/partition"), "1")
+	file(filepath.Join(nvme, "nvme0n1p1/dev"), "259:1")
+
+	// USB hard disk behind a USB-SATA bridge.
+	usb := "sys/devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/host6/target6:0:0/6:0:0:0"
+	file(filepath.Join(usb, "type"), "0")
+	file(filepath.Join(usb, "vendor"), "WD      ")
+	file(filepath.Join(usb, "model"), "My Passport 25E2")
+	disk(usb, "sdb", "8:16", "3906963456", "1", "0")
+
+	// Card reader on a platform bus: only "removable" marks it as USB-like.
+	rdr := "sys/devices/platform/soc/reader/host7/target7:0:0/7:0:0:0"
+	file(filepath.Join(rdr, "type"), "0")
+	file(filepath.Join(rdr, "vendor"), "Generic-")
+	file(filepath.Join(rdr, "model"), "SD/MMC          ")
+	disk(rdr, "sdc", "8:32", "62333952", "0", "1")
+
+	// Optical drive (SCSI type 5) and a loop device without a backing device.
+	cd := "sys/devices/pci0000:00/0000:00:17.0/ata4/host3/target3:0:0/3:0:0:0"
+	file(filepath.Join(cd, "type"), "5")
+	file(filepath.Join(cd, "model"), "DVD-RW DRU8A8SH ")
+	disk(cd, "sr0", "11:0", "2097151", "1", "1")
+	file("sys/devices/virtual/block/loop0/dev", "7:0")
+	file("sys/devices/virtual/block/loop0/size", "0")
+	link("../devices/virtual/block/loop0", "sys/block/loop0")
+
+	file("proc/self/mountinfo",
+		"26 1 253:0 / / rw,relatime shared:1 - ext4 /dev/mapper/vg-root rw\n"+
+			"27 26 259:1 / /data rw,noatime shared:2 - xfs /dev/nvme0n1p1 rw,attr2\n"+
+			"28 26 0:25 / /proc rw,nosuid shared:3 - proc proc rw")
+	return root
+}
+
+func TestDetectDisksSysfs(t *testing.T) {
+	root := fakeSysfs(t)
+	got := detectDisksSysfs(root)
+	want := []DiskInfo{
+		{
+			Device: "/dev/nvme0n1", Name: "WD_BLACK SN850X 2000GB", DiskType: "nvme", Interface: "PCIe/NVMe",
+			SizeBytes: 3907029168 * 512, MountPoint: "/data", Serial: "23154Y800123",
+		},
+		{
+			Device: "/dev/sda", Name: "Samsung SSD 870", DiskType: "ssd", Interface: "SATA",
+			SizeBytes: 976773168 * 512, MountPoint: "/", Serial: "S6PNNX0T512345",
+		},
+		{
+			Device: "/dev/sdb", Name: "My Passport 25E2", DiskType: "usb", Interface: "USB",
+			SizeBytes: 3906963456 * 512,
+		},
+		{
+			Device: "/dev/sdc", Name: "SD/MMC", DiskType: "usb", Interface: "USB",
+			SizeBytes: 62333952 * 512,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("detectDisksSysfs:\n got %+v\nwant %+v", got, want)
+	}
+}
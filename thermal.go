@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// thermalMonitor samples drive temperature in the background while a
+// benchmark runs, together with the throughput achieved between samples.
+// All methods are safe to call on a nil monitor.
+type thermalMonitor struct {
+	read     func() (int, bool)
+	result   ThermalResult
+	interval time.Duration
+	bytes    atomic.Int64
+	start    time.Time
+
+	mu    sync.Mutex
+	phase string
+	since time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// throttleDropRatio is how far throughput must fall after the drive reaches
+// its warning temperature before the drop is reported as throttling.
+const throttleDropRatio = 0.8
+
+// startThermalMonitor starts sampling the disk's temperature. It returns
+// nil when no temperature source is available.
+func startThermalMonitor(disk DiskInfo) *thermalMonitor {
+	if _, ok := noDiskHealth[disk.DiskType]; ok {
+		return nil
+	}
+	read, source, warnC := tempSensorPlatform(disk)
+	interval := time.Second
+	if read == nil {
+		smartctl := findExecutable("smartctl")
+		if smartctl == "" {
+			return nil
+		}
+		devType := smartDeviceType(disk)
+		read = func() (int, bool) {
+			out, _ := exec.Command(smartctl, smartctlArgs(disk.Device, devType, "-A", "-j")...).Output()
+			r := parseSmartctlJSON(out)
+			if r == nil || r.Temperature == 0 {
+				return 0, false
+			}
+			return r.Temperature, true
+		}
+		source = "smartctl"
+		interval = 5 * time.Second // smartctl is too slow to poll every second
+	}
+
+	first, ok := read()
+	if !ok {
+		return nil
+	}
+	if warnC == 0 {
+		// No drive-reported limit: use the health policy threshold.
+		if rule, ok := activeHealthPolicy.rule("temperature", disk.DiskType, disk.Name); ok && rule.Warn != nil {
+			warnC = int(*rule.Warn)
+		}
+	}
+
+	now := time.Now()
+	m := &thermalMonitor{
+		read:     read,
+		interval: interval,
+		start:    now,
+		since:    now,
+		result:   ThermalResult{Source: source, WarnTempC: warnC, StartC: first, PeakC: first},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+func (m *thermalMonitor) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+func (m *thermalMonitor) sample() {
+	temp, ok := m.read()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	bytes := m.bytes.Swap(0)
+	mbps := 0.0
+	if dt := now.Sub(m.since).Seconds(); dt > 0 {
+		mbps = float64(bytes) / dt / (1024 * 1024)
+	}
+	m.since = now
+	if !ok {
+		return
+	}
+	m.result.PeakC = max(m.result.PeakC, temp)
+	m.result.Samples = append(m.result.Samples, ThermalSample{
+		Elapsed: now.Sub(m.start).Seconds(), Phase: m.phase, TempC: temp, MBPS: mbps,
+	})
+}
+
+// setPhase labels the following samples; "" marks untimed work.
+func (m *thermalMonitor) setPhase(phase string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.phase = phase
+	m.since = time.Now()
+	m.bytes.Store(0)
+	m.mu.Unlock()
+}
+
+// addBytes counts bytes transferred by the running phase.
+func (m *thermalMonitor) addBytes(n int) {
+	if m != nil {
+		m.bytes.Add(int64(n))
+	}
+}
+
+// finish stops sampling and analyses the recorded series.
+func (m *thermalMonitor) finish() *ThermalResult {
+	if m == nil {
+		return nil
+	}
+	close(m.stop)
+	<-m.done
+	m.result.Throttled = detectThrottling(m.result.Samples, m.result.WarnTempC)
+	return &m.result
+}
+
+// detectThrottling reports phases whose throughput fell once the drive
+// reached its warning temperature, compared with the same phase before.
+func detectThrottling(samples []ThermalSample, warnC int) []string {
+	if warnC <= 0 {
+		return nil
+	}
+	var phases []string
+	byPhase := make(map[string][]ThermalSample)
+	for _, s := range samples {
+		if s.Phase == "" || s.MBPS <= 0 {
+			continue
+		}
+		if _, ok := byPhase[s.Phase]; !ok {
+			phases = append(phases, s.Phase)
+		}
+		byPhase[s.Phase] = append(byPhase[s.Phase], s)
+	}
+
+	var findings []string
+	for _, phase := range phases {
+		ps := byPhase[phase]
+		hot := -1
+		for i, s := range ps {
+			if s.TempC >= warnC {
+				hot = i
+				break
+			}
+		}
+		if hot < 1 {
+			continue // never got hot, or hot from the start: no baseline
+		}
+		before, after := meanMBPS(ps[:hot]), meanMBPS(ps[hot:])
+		if after < before*throttleDropRatio {
+			findings = append(findings, fmt.Sprintf(
+				"%s: %s MB/s -> %s MB/s (-%.0f%%) after reaching %dC at %.0fs",
+				phase, formatFloat(before, 1), formatFloat(after, 1),
+				(1-after/before)*100, ps[hot].TempC, ps[hot].Elapsed))
+		}
+	}
+	return findings
+}
+
+func meanMBPS(samples []ThermalSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += s.MBPS
+	}
+	return sum / float64(len(samples))
+}
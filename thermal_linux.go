@@ -0,0 +1,41 @@
+//go:build linux
+
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+)
+
+// tempSensorPlatform finds a hwmon temperature sensor for the disk: the
+// nvme driver's sensor on the controller, or drivetemp for SATA disks.
+// Returns nil when there is none.
+func tempSensorPlatform(disk DiskInfo) (read func() (int, bool), source string, warnC int) {
+	devPath := filepath.Join("/sys/block", sysfsDiskName(disk.Device), "device")
+	var inputs []string
+	for _, pattern := range []string{"hwmon*/temp1_input", "hwmon/hwmon*/temp1_input"} {
+		m, _ := filepath.Glob(filepath.Join(devPath, pattern))
+		inputs = append(inputs, m...)
+	}
+	if len(inputs) == 0 {
+		return nil, "", 0
+	}
+	input := inputs[0]
+	dir := filepath.Dir(input)
+
+	source = readSysfsFile(filepath.Join(dir, "name"))
+	if source == "" {
+		source = "hwmon"
+	}
+	if v, err := strconv.Atoi(readSysfsFile(filepath.Join(dir, "temp1_max"))); err == nil && v > 0 {
+		warnC = v / 1000
+	}
+	read = func() (int, bool) {
+		v, err := strconv.Atoi(readSysfsFile(input))
+		if err != nil {
+			return 0, false
+		}
+		return v / 1000, true // millidegrees
+	}
+	return read, source, warnC
+}
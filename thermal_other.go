@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func tempSensorPlatform(disk DiskInfo) (read func() (int, bool), source string, warnC int) {
+	return nil, "", 0 // no sysfs; startThermalMonitor falls back to smartctl
+}
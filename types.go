@@ -2,13 +2,61 @@ package main
 
 // DiskInfo represents a detected disk or storage device.
 type DiskInfo struct {
-	Device     string
-	Name       string
-	DiskType   string // nvme, ssd, hdd, usb, nfs
-	Interface  string // PCIe/NVMe, SATA, USB, Network, Apple Fabric/NVMe
-	SizeBytes  int64
-	MountPoint string
-	Serial     string
+	Device       string
+	Name         string
+	DiskType     string // nvme, ssd, hdd, usb, nfs
+	Interface    string // PCIe/NVMe, SATA, USB, Network, Apple Fabric/NVMe
+	SizeBytes    int64
+	MountPoint   string
+	Serial       string
+	IDs          []string   // persistent /dev/disk/by-id links
+	Slot         string     // physical location (/dev/disk/by-path name)
+	FSType       string     // filesystem at MountPoint, when resolved from a path
+	MountOptions []string   // mount options that affect benchmark results
+	Props        *DiskProps // queue and device properties, nil if unavailable
+	Link         *LinkInfo  // bus link speed, nil if unavailable
+	Stack        *StackNode // device-mapper / md stack, nil for plain disks
+	Members      []DiskInfo // physical disks under Stack
+}
+
+// DiskProps are block-layer and device properties that affect how benchmark
+// results should be read. Read from sysfs on Linux.
+type DiskProps struct {
+	LogicalBlock       int    // bytes
+	PhysicalBlock      int    // bytes
+	OptimalIO          int    // bytes, 0 if not reported
+	Scheduler          string // active I/O scheduler, e.g. none, mq-deadline
+	NrRequests         int
+	ReadAheadKB        int
+	Rotational         bool
+	DiscardGranularity int64  // bytes, 0 if TRIM is unsupported
+	DiscardMax         int64  // bytes
+	WriteCache         string // "write back" or "write through"
+	Firmware           string
+	WWN                string
+}
+
+// StackNode is one layer of a block device stack, top (mounted) first.
+type StackNode struct {
+	Device    string // /dev/dm-0, /dev/md0, /dev/sda2
+	Name      string // device-mapper name, e.g. vg-lv
+	Kind      string // dm-crypt, LVM, multipath, dm, md, partition, disk
+	SizeBytes int64
+	Array     *MDArray // md arrays only
+	Children  []StackNode
+}
+
+// MDArray is the state of a Linux software RAID array from /proc/mdstat.
+type MDArray struct {
+	Name     string
+	State    string // active, inactive
+	Level    string // raid1, raid5, ...
+	Devices  int    // configured members
+	Active   int    // working members
+	Status   string // per-member map, e.g. "U_"
+	Failed   []string
+	Action   string  // resync, recovery, reshape, check, or ""
+	Progress float64 // percent done for Action, -1 if unknown
 }
 
 // HealthAttr is a single SMART attribute for display.
@@ -16,6 +64,7 @@ type HealthAttr struct {
 	Name   string
 	Value  string
 	Status string // OK, WARN, FAIL
+	Key    string // health policy attribute key, empty if not policy-governed
 }
 
 // HealthResult holds the outcome of a SMART health check.
@@ -23,20 +72,86 @@ type HealthResult struct {
 	Status             string // HEALTHY, WARNING, CRITICAL, UNKNOWN, N/A
 	Temperature        int
 	PowerOnHours       int
-	WearLevel          int // percentage remaining (100 = new)
+	WearLevel          int  // percentage remaining (100 = new)
+	PercentageUsed     *int // NVMe endurance used, may pass 100; nil when not reported
 	ReallocatedSectors int
+	PendingSectors     int
 	MediaErrors        int
 	Attributes         []HealthAttr
 	Message            string
+	Model              string
+	Serial             string
+	Members            []MemberHealth // physical disks behind a RAID controller
+	KernelErrors       *KernelLog     // nil when the kernel log could not be read
+}
+
+// KernelLog summarises kernel log messages about a disk and its partitions.
+type KernelLog struct {
+	Source string         // /dev/kmsg or dmesg
+	Total  int            // matching error messages
+	Counts map[string]int // by category, e.g. "I/O error", "Link reset"
+	Recent []string       // most recent matching lines, oldest first
+}
+
+// MemberHealth is the SMART result for one physical disk behind a RAID controller.
+type MemberHealth struct {
+	Slot   string // smartctl device type, e.g. megaraid,0 or cciss,1
+	Health HealthResult
 }
 
-// SpeedResult holds sequential read/write benchmark results.
+// SpeedResult holds sequential read/write benchmark results. ReadMBPS and
+// WriteMBPS are the aggregate of all streams.
 type SpeedResult struct {
-	ReadMBPS  float64
-	WriteMBPS float64
-	TestSize  int64
-	BlockSize int
-	DirectIO  bool
+	ReadMBPS        float64
+	WriteMBPS       float64
+	TestSize        int64
+	BlockSize       int
+	Streams         int
+	QueueDepth      int       // requests in flight per stream
+	ReadStreamMBPS  []float64 // per stream, with more than one stream
+	WriteStreamMBPS []float64
+	IOMode          string   // direct, buffered or dsync, as achieved
+	IOFallback      string   // why direct I/O was not used
+	Align           dioAlign // direct I/O alignment kept
+}
+
+// ThermalSample is one drive temperature reading taken during a benchmark.
+type ThermalSample struct {
+	Elapsed float64 // seconds since monitoring started
+	Phase   string  // benchmark phase, e.g. "Sequential Write"
+	TempC   int
+	MBPS    float64 // throughput since the previous sample
+}
+
+// ThermalResult summarises drive temperature over the benchmarks.
+type ThermalResult struct {
+	Source    string // nvme, drivetemp, hwmon, smartctl
+	WarnTempC int    // drive warning threshold, 0 if unknown
+	StartC    int
+	PeakC     int
+	Samples   []ThermalSample
+	Throttled []string // throttling findings, one per affected phase
+}
+
+// DeviceIOPhase is the work the block device itself completed during one
+// benchmark phase, next to what the benchmark counted.
+type DeviceIOPhase struct {
+	Phase        string
+	Mode         string // I/O mode the phase ran in
+	Seconds      float64
+	CountedBytes int64 // bytes the benchmark read or wrote
+	ReadIOs      int64
+	WriteIOs     int64
+	ReadBytes    int64
+	WriteBytes   int64
+	UtilPct      float64 // share of the phase the device was busy
+	AvgQueue     float64 // average requests in flight, as iostat's aqu-sz
+}
+
+// DeviceIOResult holds the device counters for each benchmark phase.
+type DeviceIOResult struct {
+	Device string
+	Phases []DeviceIOPhase
 }
 
 // IOPSResult holds random I/O benchmark results.
@@ -49,4 +164,6 @@ type IOPSResult struct {
 	QueueDepth     int
 	BlockSize      int
 	Duration       float64
+	IOMode         string // direct, buffered or dsync, as achieved
+	IOFallback     string // why direct I/O was not used
 }
@@ -73,6 +73,42 @@ func parseSize(s string) int64 {
 	return val
 }
 
+// parseDuration extends time.ParseDuration with a "d" (day) suffix,
+// e.g. "7d", "1d12h".
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	days := 0.0
+	if i := strings.Index(s, "d"); i >= 0 {
+		v, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		days = v
+		s = s[i+1:]
+	}
+	d := time.Duration(0)
+	if s != "" {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	return d + time.Duration(days*24*float64(time.Hour)), nil
+}
+
+// formatDuration formats a duration coarsely (e.g. "6d 23h", "3h 5m", "42s").
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
 // formatSize formats bytes into human-readable form (e.g. "465.9 GB").
 func formatSize(bytes int64) string {
 	if bytes <= 0 {
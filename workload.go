@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	mrand "math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// workload is one parameterised I/O test. With several block sizes each
+// I/O picks one at random.
+type workload struct {
+	Name       string   `json:"name"`
+	Pattern    string   `json:"pattern"`            // random or sequential
+	BlockSizes []string `json:"block_sizes"`        // e.g. ["8K"] or ["4K", "16K", "64K"]
+	ReadPct    int      `json:"read_pct"`           // share of I/Os that are reads, 0-100
+	QueueDepth int      `json:"queue_depth"`        // I/Os in flight per thread
+	Threads    int      `json:"threads,omitempty"`  // workers; separate streams when sequential, 0 = 1
+	Fsync      bool     `json:"fsync,omitempty"`    // fsync after every write
+	Duration   int      `json:"duration,omitempty"` // seconds, 0 = --duration
+}
+
+// workloadResult is the outcome of one workload run.
+type workloadResult struct {
+	Name       string
+	Ops        int64
+	Bytes      int64
+	Seconds    float64
+	IOPS       float64
+	MBPS       float64
+	AvgLatUS   float64
+	P99LatUS   float64
+	Errors     int64
+	IOMode     string // direct, buffered or dsync, as achieved
+	IOFallback string // why direct I/O was not used
+}
+
+// validate checks a workload read from a profile file.
+func (w workload) validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("test without a name")
+	}
+	if w.Pattern != "random" && w.Pattern != "sequential" {
+		return fmt.Errorf("%s: pattern must be random or sequential, not %q", w.Name, w.Pattern)
+	}
+	if len(w.BlockSizes) == 0 {
+		return fmt.Errorf("%s: no block_sizes", w.Name)
+	}
+	for _, s := range w.BlockSizes {
+		if bs := parseSize(s); bs < 512 || bs%512 != 0 || bs > 64*1024*1024 {
+			return fmt.Errorf("%s: block size %q must be a multiple of 512 bytes up to 64M", w.Name, s)
+		}
+	}
+	if w.ReadPct < 0 || w.ReadPct > 100 {
+		return fmt.Errorf("%s: read_pct must be 0-100", w.Name)
+	}
+	if w.QueueDepth < 1 || w.QueueDepth > 1024 {
+		return fmt.Errorf("%s: queue_depth must be 1-1024", w.Name)
+	}
+	if w.Threads < 0 || w.Threads > 64 {
+		return fmt.Errorf("%s: threads must be 0-64 (0 means 1)", w.Name)
+	}
+	return nil
+}
+
+// threads returns the number of workers, at least one.
+func (w workload) threads() int {
+	return max(w.Threads, 1)
+}
+
+// describe summarises the workload, e.g. "8K random 70/30 QD16 fsync".
+func (w workload) describe() string {
+	s := strings.Join(w.BlockSizes, "/") + " " + w.Pattern
+	switch w.ReadPct {
+	case 100:
+		s += " read"
+	case 0:
+		s += " write"
+	default:
+		s += fmt.Sprintf(" %d/%d", w.ReadPct, 100-w.ReadPct)
+	}
+	s += fmt.Sprintf(" QD%d", w.QueueDepth)
+	if w.threads() > 1 {
+		s += fmt.Sprintf(" T%d", w.threads())
+	}
+	if w.Fsync {
+		s += " fsync"
+	}
+	return s
+}
+
+// latencyBuckets covers 1us to about 70 minutes at 8 buckets per doubling,
+// so percentiles are accurate to about 9%.
+const latencyBuckets = 8 * 32
+
+// latencyHist is a log-scale I/O latency histogram.
+type latencyHist struct {
+	counts [latencyBuckets]int64
+	total  int64
+	sumUS  float64
+}
+
+func (h *latencyHist) add(d time.Duration) {
+	us := float64(d.Nanoseconds()) / 1000
+	b := min(int(math.Log2(1+us)*8), latencyBuckets-1)
+	h.counts[b]++
+	h.total++
+	h.sumUS += us
+}
+
+func (h *latencyHist) merge(o *latencyHist) {
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	h.sumUS += o.sumUS
+}
+
+func (h *latencyHist) mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sumUS / float64(h.total)
+}
+
+// percentile returns the upper edge of the bucket holding the p-th
+// percentile latency, in microseconds.
+func (h *latencyHist) percentile(p float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	want := int64(math.Ceil(float64(h.total) * p / 100))
+	var seen int64
+	for b, c := range h.counts {
+		seen += c
+		if seen >= want {
+			return math.Exp2(float64(b+1)/8) - 1
+		}
+	}
+	return math.Exp2(float64(latencyBuckets)/8) - 1
+}
+
+// runWorkload runs w against the existing test file at path in
+// benchIOMode. Without direct I/O writes land in the page cache, so each
+// stream also syncs once at the end and the flush counts in the elapsed time.
+//
+// Each thread keeps QueueDepth I/Os in flight with one goroutine per slot.
+// A sequential thread is a single stream: its goroutines take consecutive
+// blocks from a shared cursor within the thread's region of the file.
+func runWorkload(w workload, path string, fileSize int64, defaultDuration int, mon benchObserver) workloadResult {
+	duration := w.Duration
+	if duration <= 0 {
+		duration = defaultDuration
+	}
+	if duration <= 0 {
+		duration = 10
+	}
+	// Direct I/O needs transfers and offsets aligned for this file
+	align := benchAlignment(path)
+	sizes := make([]int64, len(w.BlockSizes))
+	maxBS := int64(0)
+	for i, s := range w.BlockSizes {
+		sizes[i] = int64(align.roundUp(int(parseSize(s))))
+		if sizes[i] != parseSize(s) {
+			fmt.Fprintf(progressOut, "  %s: %s blocks raised to %s for direct I/O alignment\n",
+				w.Name, s, formatSize(sizes[i]))
+		}
+		maxBS = max(maxBS, sizes[i])
+	}
+	gran := int64(align.roundUp(iopsBlockSize))
+	result := workloadResult{Name: w.Name}
+	threads := w.threads()
+	if fileSize < maxBS*int64(threads*w.QueueDepth) {
+		fmt.Fprintf(progressOut, "  %s: test file too small\n", w.Name)
+		return result
+	}
+	// Sequential streams each get their own region of the file.
+	region := fileSize / int64(threads) / maxBS * maxBS
+	cursors := make([]atomic.Int64, threads)
+
+	var mu sync.Mutex
+	var hist latencyHist
+	var mode achievedMode
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(time.Duration(duration) * time.Second)
+
+	mon.setPhase(w.Name)
+	defer mon.setPhase("")
+	start := time.Now()
+	for q := 0; q < threads*w.QueueDepth; q++ {
+		t := q / w.QueueDepth
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local latencyHist
+			var bytes, errs int64
+			defer func() {
+				mu.Lock()
+				hist.merge(&local)
+				result.Bytes += bytes
+				result.Errors += errs
+				mu.Unlock()
+			}()
+
+			f, err := openBenchFile(path, os.O_RDWR, &mode)
+			if err != nil {
+				errs++
+				return
+			}
+			defer f.Close()
+
+			buf := alignedBuffer(int(maxBS), align.Mem)
+			rand.Read(buf)
+			rng := mrand.New(mrand.NewPCG(uint64(time.Now().UnixNano()), uint64(q)))
+			wrote := false
+			for time.Now().Before(deadline) {
+				bs := sizes[rng.IntN(len(sizes))]
+				var off int64
+				if w.Pattern == "sequential" {
+					// Next block of the thread's stream, wrapping within its region.
+					pos := (cursors[t].Add(bs) - bs) % region
+					if pos+bs > region {
+						pos = 0
+					}
+					off = int64(t)*region + pos
+				} else {
+					off = rng.Int64N((fileSize-bs)/gran+1) * gran
+				}
+
+				t0 := time.Now()
+				if rng.IntN(100) < w.ReadPct {
+					_, err = f.ReadAt(buf[:bs], off)
+				} else {
+					_, err = f.WriteAt(buf[:bs], off)
+					if err == nil && w.Fsync {
+						err = f.Sync()
+					}
+					wrote = true
+				}
+				if err != nil {
+					errs++
+					return
+				}
+				local.add(time.Since(t0))
+				bytes += bs
+				mon.addBytes(int(bs))
+			}
+			if wrote && !w.Fsync {
+				if err := f.Sync(); err != nil {
+					errs++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.Seconds = time.Since(start).Seconds()
+	result.Ops = hist.total
+	result.IOPS = float64(hist.total) / result.Seconds
+	result.MBPS = float64(result.Bytes) / result.Seconds / (1024 * 1024)
+	result.AvgLatUS = hist.mean()
+	result.P99LatUS = hist.percentile(99)
+	result.IOMode, result.IOFallback = mode.get()
+	return result
+}